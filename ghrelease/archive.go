@@ -0,0 +1,114 @@
+package ghrelease
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+)
+
+const syntheticRootDir = "release/"
+
+type archiveEntry struct {
+	path string
+	open func() (io.ReadCloser, error)
+}
+
+func (e archiveEntry) read() ([]byte, error) {
+	rc, err := e.open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+func (u *Updater) readArchive(release *Release, data []byte) ([]archiveEntry, error) {
+	return archiveEntries(data, release != nil && release.FlatArchive)
+}
+
+func archiveEntries(data []byte, flat bool) ([]archiveEntry, error) {
+	var raw []archiveEntry
+	var err error
+	if isGzip(data) {
+		raw, err = readTarGz(data)
+	} else {
+		raw, err = readZip(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []archiveEntry
+	for _, entry := range raw {
+		relPath := strings.TrimPrefix(entry.path, "./")
+		if !flat {
+			relPath = stripRootDir(relPath)
+		}
+		if relPath == "" {
+			continue
+		}
+
+		entry.path = relPath
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func readZip(data []byte) ([]archiveEntry, error) {
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []archiveEntry
+	for _, file := range zipReader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		entries = append(entries, archiveEntry{path: file.Name, open: file.Open})
+	}
+	return entries, nil
+}
+
+func readTarGz(data []byte) ([]archiveEntry, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	return readTar(gz)
+}
+
+func readTar(r io.Reader) ([]archiveEntry, error) {
+	var entries []archiveEntry
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, archiveEntry{path: header.Name, open: func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(content)), nil
+		}})
+	}
+}
+
+func isGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
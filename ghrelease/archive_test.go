@@ -0,0 +1,106 @@
+package ghrelease
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"testing"
+)
+
+func createTestTar(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	w := tar.NewWriter(buf)
+
+	for name, content := range files {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := w.WriteHeader(header); err != nil {
+			t.Fatalf("failed to write tar header %s: %v", name, err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write tar entry %s: %v", name, err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close tar writer: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func createTestTarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	return gzipBytes(t, createTestTar(t, files))
+}
+
+func TestUpdater_readArchive(t *testing.T) {
+	files := map[string]string{"root/agents/a.md": "a", "root/README.md": "readme"}
+	flatFiles := map[string]string{"./agents/a.md": "a", "README.md": "readme"}
+
+	tests := []struct {
+		name    string
+		release *Release
+		data    []byte
+		want    map[string]string
+	}{
+		{name: "zip", data: createTestZip(t, files), want: map[string]string{"agents/a.md": "a", "README.md": "readme"}},
+		{name: "tar.gz", data: createTestTarGz(t, files), want: map[string]string{"agents/a.md": "a", "README.md": "readme"}},
+		{name: "flat zip", release: &Release{FlatArchive: true}, data: createTestZip(t, flatFiles), want: map[string]string{"agents/a.md": "a", "README.md": "readme"}},
+		{name: "flat tar.gz", release: &Release{FlatArchive: true}, data: createTestTarGz(t, flatFiles), want: map[string]string{"agents/a.md": "a", "README.md": "readme"}},
+		{name: "root stripped from flat archive", data: createTestTarGz(t, flatFiles), want: map[string]string{"a.md": "a"}},
+	}
+
+	updater := &Updater{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := updater.readArchive(tt.release, tt.data)
+			if err != nil {
+				t.Fatalf("readArchive() error = %v", err)
+			}
+
+			got := make(map[string]string, len(entries))
+			for _, entry := range entries {
+				content, err := entry.read()
+				if err != nil {
+					t.Fatalf("read(%s) error = %v", entry.path, err)
+				}
+				got[entry.path] = string(content)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readArchive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdater_readArchive_invalid(t *testing.T) {
+	updater := &Updater{}
+	for name, data := range map[string][]byte{
+		"garbage":     []byte("not an archive"),
+		"bad gzip":    {0x1f, 0x8b, 0x00},
+		"gzip no tar": gzipBytes(t, []byte("plain text that is not a tarball, padded to exceed a block")),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := updater.readArchive(nil, data); err == nil {
+				t.Error("readArchive() should fail")
+			}
+		})
+	}
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatalf("gzip write: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip close: %v", err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,294 @@
+package ghrelease
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrNoMatchingAsset = errors.New("no matching asset")
+
+const (
+	archUniversal = "universal"
+	libcGNU       = "gnu"
+	libcMusl      = "musl"
+)
+
+var (
+	assetTokenSplit = regexp.MustCompile(`[^a-z0-9]+`)
+	assetX8664      = regexp.MustCompile(`x86[-_]64`)
+	assetARMVersion = regexp.MustCompile(`^armv([5-7])[a-z]*$`)
+
+	archiveExtensions = []string{".tar.gz", ".tgz", ".tar.xz", ".txz", ".tar.bz2", ".tbz2", ".tar.zst", ".tar", ".zip", ".gz", ".xz", ".bz2", ".zst", ".exe"}
+	ignoredExtensions = []string{".sha256", ".sha512", ".md5", ".sig", ".asc", ".pem", ".crt", ".sbom", ".spdx", ".json", ".txt", ".deb", ".rpm", ".apk", ".msi", ".dmg", ".pkg"}
+
+	osAliases = map[string]string{
+		"linux":     "linux",
+		"darwin":    "darwin",
+		"macos":     "darwin",
+		"mac":       "darwin",
+		"osx":       "darwin",
+		"apple":     "darwin",
+		"windows":   "windows",
+		"win":       "windows",
+		"win32":     "windows",
+		"win64":     "windows",
+		"freebsd":   "freebsd",
+		"openbsd":   "openbsd",
+		"netbsd":    "netbsd",
+		"android":   "android",
+		"illumos":   "illumos",
+		"solaris":   "solaris",
+		"dragonfly": "dragonfly",
+		"aix":       "aix",
+		"plan9":     "plan9",
+		"ios":       "ios",
+	}
+	archAliases = map[string]string{
+		"amd64":     "amd64",
+		"x64":       "amd64",
+		"64bit":     "amd64",
+		"win64":     "amd64",
+		"386":       "386",
+		"i386":      "386",
+		"i686":      "386",
+		"x86":       "386",
+		"32bit":     "386",
+		"win32":     "386",
+		"arm64":     "arm64",
+		"aarch64":   "arm64",
+		"armv8":     "arm64",
+		"arm":       "arm",
+		"armhf":     "arm",
+		"armel":     "arm",
+		"ppc64le":   "ppc64le",
+		"ppc64":     "ppc64",
+		"s390x":     "s390x",
+		"riscv64":   "riscv64",
+		"mips":      "mips",
+		"mipsle":    "mipsle",
+		"mips64":    "mips64",
+		"mips64le":  "mips64le",
+		"loong64":   "loong64",
+		"universal": archUniversal,
+	}
+	libcAliases = map[string]string{
+		"gnu":        libcGNU,
+		"glibc":      libcGNU,
+		"gnueabi":    libcGNU,
+		"gnueabihf":  libcGNU,
+		"musl":       libcMusl,
+		"musleabi":   libcMusl,
+		"musleabihf": libcMusl,
+	}
+	armVersionAliases = map[string]int{
+		"armhf":      7,
+		"gnueabihf":  7,
+		"musleabihf": 7,
+		"armel":      5,
+		"gnueabi":    5,
+		"musleabi":   5,
+	}
+)
+
+type assetPlatform struct {
+	os         []string
+	arch       []string
+	armVersion int
+	libc       string
+}
+
+func (m *AssetMatcher) Match(assets []Asset) (*Asset, []AssetMatch, error) {
+	matches := m.Explain(assets)
+	if len(matches) > 0 && matches[0].Accepted {
+		best := matches[0].Asset
+		return &best, matches, nil
+	}
+	return nil, matches, fmt.Errorf("%w for %s:\n%s", ErrNoMatchingAsset, m.platform(), FormatAssetMatches(matches))
+}
+
+func (m *AssetMatcher) Explain(assets []Asset) []AssetMatch {
+	matches := make([]AssetMatch, len(assets))
+	for i, asset := range assets {
+		matches[i] = m.score(asset)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Accepted != matches[j].Accepted {
+			return matches[i].Accepted
+		}
+		return matches[i].Score > matches[j].Score
+	})
+	return matches
+}
+
+func FormatAssetMatches(matches []AssetMatch) string {
+	var b strings.Builder
+	for _, match := range matches {
+		status := "rejected"
+		if match.Accepted {
+			status = "accepted"
+		}
+		fmt.Fprintf(&b, "  %s: %s (score %d): %s\n", match.Asset.Name, status, match.Score, strings.Join(match.Reasons, "; "))
+	}
+	return b.String()
+}
+
+func (m *AssetMatcher) score(asset Asset) AssetMatch {
+	match := AssetMatch{Asset: asset, Accepted: true}
+	reject := func(format string, args ...any) {
+		match.Accepted = false
+		match.Reasons = append(match.Reasons, fmt.Sprintf(format, args...))
+	}
+	note := func(points int, format string, args ...any) {
+		match.Score += points
+		match.Reasons = append(match.Reasons, fmt.Sprintf(format, args...))
+	}
+
+	name := strings.ToLower(asset.Name)
+	for _, ext := range ignoredExtensions {
+		if strings.HasSuffix(name, ext) {
+			reject("%s files are not binaries or archives", ext)
+			return match
+		}
+	}
+
+	platform := parseAssetPlatform(name)
+	wantOS, wantArch := m.goos(), m.goarch()
+
+	if m.Name != "" && !strings.Contains(name, strings.ToLower(m.Name)) {
+		reject("name does not contain %q", m.Name)
+	}
+
+	switch {
+	case len(platform.os) == 0:
+		note(0, "no os in name")
+	case contains(platform.os, wantOS):
+		note(10, "os %s matches", wantOS)
+	default:
+		reject("os %s does not match %s", strings.Join(platform.os, "/"), wantOS)
+	}
+
+	switch {
+	case len(platform.arch) == 0:
+		note(0, "no arch in name")
+	case contains(platform.arch, wantArch):
+		note(10, "arch %s matches", wantArch)
+	case contains(platform.arch, archUniversal) && wantOS == "darwin":
+		note(5, "universal binary covers %s", wantArch)
+	default:
+		reject("arch %s does not match %s", strings.Join(platform.arch, "/"), wantArch)
+	}
+
+	if wantArch == "arm" && contains(platform.arch, "arm") && platform.armVersion > 0 {
+		want := m.armVersion()
+		switch {
+		case platform.armVersion == want:
+			note(3, "armv%d matches", want)
+		case platform.armVersion < want:
+			note(1, "armv%d runs on armv%d", platform.armVersion, want)
+		default:
+			reject("armv%d does not run on armv%d", platform.armVersion, want)
+		}
+	}
+
+	switch {
+	case platform.libc == "":
+	case m.Libc == "":
+		if platform.libc == libcMusl {
+			note(1, "static musl build preferred")
+		} else {
+			note(0, "%s libc build", platform.libc)
+		}
+	case platform.libc == m.Libc:
+		note(2, "libc %s matches", m.Libc)
+	default:
+		reject("libc %s does not match %s", platform.libc, m.Libc)
+	}
+
+	return match
+}
+
+func parseAssetPlatform(name string) assetPlatform {
+	for trimmed := true; trimmed; {
+		trimmed = false
+		for _, ext := range archiveExtensions {
+			if strings.HasSuffix(name, ext) {
+				name = strings.TrimSuffix(name, ext)
+				trimmed = true
+			}
+		}
+	}
+	name = assetX8664.ReplaceAllString(name, "amd64")
+
+	var platform assetPlatform
+	for _, token := range assetTokenSplit.Split(name, -1) {
+		if token == "" {
+			continue
+		}
+		if os, ok := osAliases[token]; ok && !contains(platform.os, os) {
+			platform.os = append(platform.os, os)
+		}
+		if arch, ok := archAliases[token]; ok && !contains(platform.arch, arch) {
+			platform.arch = append(platform.arch, arch)
+		}
+		if libc, ok := libcAliases[token]; ok {
+			platform.libc = libc
+		}
+		if version, ok := armVersionAliases[token]; ok {
+			platform.armVersion = version
+		}
+		if groups := assetARMVersion.FindStringSubmatch(token); groups != nil {
+			version, _ := strconv.Atoi(groups[1])
+			platform.armVersion = version
+			if !contains(platform.arch, "arm") {
+				platform.arch = append(platform.arch, "arm")
+			}
+		}
+	}
+	return platform
+}
+
+func (m *AssetMatcher) goos() string {
+	if m.OS != "" {
+		return m.OS
+	}
+	return runtime.GOOS
+}
+
+func (m *AssetMatcher) goarch() string {
+	if m.Arch != "" {
+		return m.Arch
+	}
+	return runtime.GOARCH
+}
+
+func (m *AssetMatcher) armVersion() int {
+	if m.ARMVersion != 0 {
+		return m.ARMVersion
+	}
+	return 7
+}
+
+func (m *AssetMatcher) platform() string {
+	platform := m.goos() + "/" + m.goarch()
+	if m.goarch() == "arm" {
+		platform += "v" + strconv.Itoa(m.armVersion())
+	}
+	if m.Libc != "" {
+		platform += " (" + m.Libc + ")"
+	}
+	return platform
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
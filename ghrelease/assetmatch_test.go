@@ -0,0 +1,165 @@
+package ghrelease
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func assetsNamed(names ...string) []Asset {
+	assets := make([]Asset, len(names))
+	for i, name := range names {
+		assets[i] = Asset{ID: int64(i + 1), Name: name}
+	}
+	return assets
+}
+
+func TestAssetMatcher_Match(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher AssetMatcher
+		assets  []string
+		want    string
+	}{
+		{
+			name:    "goreleaser title case x86_64",
+			matcher: AssetMatcher{OS: "linux", Arch: "amd64"},
+			assets:  []string{"tool_Darwin_x86_64.tar.gz", "tool_Linux_arm64.tar.gz", "tool_Linux_x86_64.tar.gz", "checksums.txt"},
+			want:    "tool_Linux_x86_64.tar.gz",
+		},
+		{
+			name:    "dash separated zip",
+			matcher: AssetMatcher{OS: "windows", Arch: "amd64"},
+			assets:  []string{"tool-linux-amd64.zip", "tool-windows-amd64.zip", "tool-windows-386.zip"},
+			want:    "tool-windows-amd64.zip",
+		},
+		{
+			name:    "rust triple with musl",
+			matcher: AssetMatcher{OS: "linux", Arch: "arm64", Libc: "musl"},
+			assets:  []string{"tool-v1.2.3-aarch64-unknown-linux-gnu.tar.xz", "tool-v1.2.3-aarch64-unknown-linux-musl.tar.xz", "tool-v1.2.3-x86_64-unknown-linux-musl.tar.xz"},
+			want:    "tool-v1.2.3-aarch64-unknown-linux-musl.tar.xz",
+		},
+		{
+			name:    "rust triple prefers musl when libc unset",
+			matcher: AssetMatcher{OS: "linux", Arch: "amd64"},
+			assets:  []string{"tool-x86_64-unknown-linux-gnu.tar.gz", "tool-x86_64-unknown-linux-musl.tar.gz"},
+			want:    "tool-x86_64-unknown-linux-musl.tar.gz",
+		},
+		{
+			name:    "macos alias",
+			matcher: AssetMatcher{OS: "darwin", Arch: "arm64"},
+			assets:  []string{"tool-macos-x64.tar.gz", "tool-macos-aarch64.tar.gz"},
+			want:    "tool-macos-aarch64.tar.gz",
+		},
+		{
+			name:    "darwin universal",
+			matcher: AssetMatcher{OS: "darwin", Arch: "arm64"},
+			assets:  []string{"tool-linux-arm64.tar.gz", "tool-darwin-universal.tar.gz"},
+			want:    "tool-darwin-universal.tar.gz",
+		},
+		{
+			name:    "exact arch beats universal",
+			matcher: AssetMatcher{OS: "darwin", Arch: "amd64"},
+			assets:  []string{"tool-darwin-universal.tar.gz", "tool-darwin-amd64.tar.gz"},
+			want:    "tool-darwin-amd64.tar.gz",
+		},
+		{
+			name:    "armv7 exact",
+			matcher: AssetMatcher{OS: "linux", Arch: "arm", ARMVersion: 7},
+			assets:  []string{"tool_linux_armv6.tar.gz", "tool_linux_armv7.tar.gz", "tool_linux_arm64.tar.gz"},
+			want:    "tool_linux_armv7.tar.gz",
+		},
+		{
+			name:    "armv6 runs on armv7",
+			matcher: AssetMatcher{OS: "linux", Arch: "arm", ARMVersion: 7},
+			assets:  []string{"tool_linux_armv6.tar.gz"},
+			want:    "tool_linux_armv6.tar.gz",
+		},
+		{
+			name:    "armhf alias",
+			matcher: AssetMatcher{OS: "linux", Arch: "arm"},
+			assets:  []string{"tool-armhf-linux", "tool-arm64-linux"},
+			want:    "tool-armhf-linux",
+		},
+		{
+			name:    "i686 for 386",
+			matcher: AssetMatcher{OS: "windows", Arch: "386"},
+			assets:  []string{"tool-x86_64-pc-windows-msvc.zip", "tool-i686-pc-windows-msvc.zip"},
+			want:    "tool-i686-pc-windows-msvc.zip",
+		},
+		{
+			name:    "name filter",
+			matcher: AssetMatcher{OS: "linux", Arch: "amd64", Name: "server"},
+			assets:  []string{"client_linux_amd64.tar.gz", "server_linux_amd64.tar.gz"},
+			want:    "server_linux_amd64.tar.gz",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asset, _, err := tt.matcher.Match(assetsNamed(tt.assets...))
+			if err != nil {
+				t.Fatalf("Match() error = %v", err)
+			}
+			if asset.Name != tt.want {
+				t.Errorf("Match() = %q, want %q", asset.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssetMatcher_Match_noMatch(t *testing.T) {
+	matcher := AssetMatcher{OS: "linux", Arch: "arm", ARMVersion: 6, Libc: "gnu"}
+	_, matches, err := matcher.Match(assetsNamed(
+		"tool_linux_armv7.tar.gz",
+		"tool-arm-unknown-linux-musleabi.tar.gz",
+		"tool_darwin_arm64.tar.gz",
+		"tool_linux_armv6.tar.gz.sha256",
+	))
+	if !errors.Is(err, ErrNoMatchingAsset) {
+		t.Fatalf("Match() error = %v, want ErrNoMatchingAsset", err)
+	}
+
+	wantReasons := map[string]string{
+		"tool_linux_armv7.tar.gz":                "armv7 does not run on armv6",
+		"tool-arm-unknown-linux-musleabi.tar.gz": "libc musl does not match gnu",
+		"tool_darwin_arm64.tar.gz":               "os darwin does not match linux",
+		"tool_linux_armv6.tar.gz.sha256":         ".sha256 files are not binaries",
+	}
+	if len(matches) != len(wantReasons) {
+		t.Fatalf("matches = %d, want %d", len(matches), len(wantReasons))
+	}
+	for _, match := range matches {
+		if match.Accepted {
+			t.Errorf("%s accepted", match.Asset.Name)
+		}
+		if want := wantReasons[match.Asset.Name]; !strings.Contains(strings.Join(match.Reasons, "; "), want) {
+			t.Errorf("%s reasons = %v, want %q", match.Asset.Name, match.Reasons, want)
+		}
+		if !strings.Contains(err.Error(), match.Asset.Name) {
+			t.Errorf("error does not explain %s: %v", match.Asset.Name, err)
+		}
+	}
+}
+
+func TestAssetMatcher_Explain(t *testing.T) {
+	matcher := AssetMatcher{OS: "linux", Arch: "amd64"}
+	matches := matcher.Explain(assetsNamed("tool.tar.gz", "tool_windows_amd64.zip", "tool_linux_amd64.tar.gz"))
+
+	want := []struct {
+		name     string
+		accepted bool
+	}{
+		{"tool_linux_amd64.tar.gz", true},
+		{"tool.tar.gz", true},
+		{"tool_windows_amd64.zip", false},
+	}
+	for i, w := range want {
+		if matches[i].Asset.Name != w.name || matches[i].Accepted != w.accepted {
+			t.Errorf("matches[%d] = %s accepted=%v, want %s accepted=%v", i, matches[i].Asset.Name, matches[i].Accepted, w.name, w.accepted)
+		}
+	}
+	if matches[1].Score >= matches[0].Score {
+		t.Errorf("platform-less asset scored %d, want below %d", matches[1].Score, matches[0].Score)
+	}
+}
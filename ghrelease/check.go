@@ -0,0 +1,24 @@
+package ghrelease
+
+import (
+	"context"
+	"fmt"
+)
+
+func (u *Updater) Check(ctx context.Context) (*CheckResult, error) {
+	release, err := u.latestRelease(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get latest release: %w", err)
+	}
+
+	metadata := u.loadMetadata()
+	reason := u.updateReason(metadata, release.Tag)
+
+	return &CheckResult{
+		LocalVersion:    metadata.Version,
+		LatestVersion:   release.Tag,
+		Release:         release,
+		UpdateAvailable: reason != "",
+		Reason:          reason,
+	}, nil
+}
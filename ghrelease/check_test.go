@@ -0,0 +1,109 @@
+package ghrelease
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUpdater_Check(t *testing.T) {
+	tmpDir := t.TempDir()
+	destDir := filepath.Join(tmpDir, "dest")
+
+	latest := "v1.0.0"
+	server := newFakeGitHub(t, &latest, map[string]fakeRelease{
+		"v1.0.0": {tag: "v1.0.0", files: map[string]string{"a.md": "a"}},
+		"v2.0.0": {tag: "v2.0.0", files: map[string]string{"a.md": "b"}},
+	})
+
+	updater := mustNewUpdater(t, UpdaterConfig{
+		MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+		Targets:      []ExtractTarget{{PathTransformer: &KeepAllTransformer{}, DestDir: destDir}},
+	})
+	useFakeGitHub(t, updater, server)
+
+	result, err := updater.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if !result.UpdateAvailable || result.LocalVersion != "" || result.LatestVersion != "v1.0.0" {
+		t.Errorf("Check() = %+v, want update from \"\" to v1.0.0", result)
+	}
+
+	release := result.Release
+	if release.Name != "Release v1.0.0" || release.Body != "notes for v1.0.0" {
+		t.Errorf("release name/body = %q/%q", release.Name, release.Body)
+	}
+	if release.HTMLURL != "https://github.com/owner/repo/releases/tag/v1.0.0" {
+		t.Errorf("HTMLURL = %q", release.HTMLURL)
+	}
+	if want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC); !release.PublishedAt.Equal(want) {
+		t.Errorf("PublishedAt = %v, want %v", release.PublishedAt, want)
+	}
+	if len(release.Assets) != 1 || release.Assets[0].Name != "pack.zip" || release.Assets[0].Size != 123 {
+		t.Errorf("Assets = %+v", release.Assets)
+	}
+
+	if _, err := updater.UpdateWithResult(context.Background()); err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+
+	result, err = updater.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if result.UpdateAvailable || result.LocalVersion != "v1.0.0" {
+		t.Errorf("Check() = %+v, want up to date", result)
+	}
+
+	latest = "v2.0.0"
+	result, err = updater.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if !result.UpdateAvailable || result.Reason != UpdateReasonVersionChanged || result.LatestVersion != "v2.0.0" {
+		t.Errorf("Check() = %+v, want update to v2.0.0", result)
+	}
+	if got := updater.getLocalVersion(); got != "v1.0.0" {
+		t.Errorf("Check() should not install, local version = %q", got)
+	}
+}
+
+func TestUpdater_Check_cacheTTL(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"tag_name": "v1.0.0"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	updater := mustNewUpdater(t, UpdaterConfig{CacheTTL: time.Hour})
+	useFakeGitHub(t, updater, server)
+
+	for i := 0; i < 3; i++ {
+		if _, err := updater.Check(context.Background()); err != nil {
+			t.Fatalf("Check() error = %v", err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("API calls = %d, want 1", got)
+	}
+}
+
+func TestUpdater_Check_error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+
+	updater := mustNewUpdater(t, UpdaterConfig{})
+	useFakeGitHub(t, updater, server)
+
+	if _, err := updater.Check(context.Background()); err == nil {
+		t.Error("Check() should return error when the API fails")
+	}
+}
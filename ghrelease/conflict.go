@@ -0,0 +1,184 @@
+package ghrelease
+
+import (
+	"os"
+)
+
+const (
+	newFileSuffix    = ".new"
+	backupFileSuffix = ".orig"
+)
+
+func (u *Updater) installFile(target ExtractTarget, path string, content []byte, previousHash string, result *UpdateResult) (bool, error) {
+	newHash := hashBytes(content)
+	policy := target.conflictPolicy()
+
+	if policy == ConflictMerge {
+		if err := u.pristine.put(content); err != nil {
+			return false, err
+		}
+	}
+
+	localHash, err := hashFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	if localHash == newHash {
+		return false, nil
+	}
+	if !isLocallyModified(policy, localHash, previousHash) {
+		return true, writeFile(path, content)
+	}
+
+	if policy == ConflictMerge {
+		merge, err := u.mergeFile(target, path, content, previousHash)
+		if err != nil {
+			return false, err
+		}
+		result.Merges = append(result.Merges, *merge)
+		if merge.Status != MergeFallback {
+			return true, nil
+		}
+		policy = merge.Fallback
+	}
+
+	conflict, err := resolveConflict(policy, path, content)
+	if err != nil {
+		return false, err
+	}
+	result.Conflicts = append(result.Conflicts, *conflict)
+	return policy == ConflictOverwrite || policy == ConflictBackupLocal, nil
+}
+
+func (u *Updater) mergeFile(target ExtractTarget, path string, content []byte, previousHash string) (*MergeResult, error) {
+	merge := &MergeResult{Path: path}
+
+	local, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := u.pristine.get(previousHash)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if base == nil || isBinary(base) || isBinary(local) || isBinary(content) {
+		merge.Status = MergeFallback
+		merge.Fallback = target.mergeFallback()
+		return merge, nil
+	}
+
+	merged, conflicts := merge3(base, local, content)
+	merge.Conflicts = conflicts
+	switch {
+	case conflicts == 0:
+		merge.Status = MergeClean
+	case target.MergeFallback == "":
+		merge.Status = MergeConflicts
+	default:
+		merge.Status = MergeFallback
+		merge.Fallback = target.MergeFallback
+		return merge, nil
+	}
+
+	return merge, writeFile(path, merged)
+}
+
+func resolveConflict(policy ConflictPolicy, path string, content []byte) (*Conflict, error) {
+	conflict := &Conflict{Path: path, Policy: policy}
+	switch policy {
+	case ConflictKeepLocal:
+	case ConflictWriteNew:
+		conflict.SavedPath = path + newFileSuffix
+		if err := writeFile(conflict.SavedPath, content); err != nil {
+			return nil, err
+		}
+	case ConflictBackupLocal:
+		conflict.SavedPath = path + backupFileSuffix
+		if err := os.Rename(path, conflict.SavedPath); err != nil {
+			return nil, err
+		}
+		if err := writeFile(path, content); err != nil {
+			return nil, err
+		}
+	default:
+		if err := writeFile(path, content); err != nil {
+			return nil, err
+		}
+	}
+	return conflict, nil
+}
+
+func (u *Updater) removeOwnedFile(target ExtractTarget, path, previousHash string) (bool, *Conflict, error) {
+	localHash, err := hashFile(path)
+	if os.IsNotExist(err) {
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, err
+	}
+	policy := target.removalPolicy()
+	if !isLocallyModified(policy, localHash, previousHash) {
+		return true, nil, os.Remove(path)
+	}
+
+	conflict := &Conflict{Path: path, Policy: policy, Removed: true}
+	switch policy {
+	case ConflictKeepLocal, ConflictWriteNew:
+	case ConflictBackupLocal:
+		conflict.SavedPath = path + backupFileSuffix
+		if err := os.Rename(path, conflict.SavedPath); err != nil {
+			return false, nil, err
+		}
+	default:
+		if err := os.Remove(path); err != nil {
+			return false, nil, err
+		}
+	}
+	return policy == ConflictOverwrite || policy == ConflictBackupLocal, conflict, nil
+}
+
+func (u *Updater) prunePristine(files map[string]string) error {
+	if u.pristine == nil {
+		return nil
+	}
+
+	keep := make(map[string]bool, len(files))
+	for path, hash := range files {
+		if i, ok := u.targetFor(path); ok && u.config.Targets[i].conflictPolicy() == ConflictMerge {
+			keep[hash] = true
+		}
+	}
+	return u.pristine.prune(keep)
+}
+
+func isLocallyModified(policy ConflictPolicy, localHash, previousHash string) bool {
+	if localHash == "" {
+		return false
+	}
+	if previousHash == "" {
+		return policy != ConflictOverwrite
+	}
+	return localHash != previousHash
+}
+
+func (t ExtractTarget) conflictPolicy() ConflictPolicy {
+	if t.ConflictPolicy == "" {
+		return ConflictOverwrite
+	}
+	return t.ConflictPolicy
+}
+
+func (t ExtractTarget) removalPolicy() ConflictPolicy {
+	if policy := t.conflictPolicy(); policy != ConflictMerge {
+		return policy
+	}
+	return t.mergeFallback()
+}
+
+func (t ExtractTarget) mergeFallback() ConflictPolicy {
+	if t.MergeFallback == "" {
+		return ConflictKeepLocal
+	}
+	return t.MergeFallback
+}
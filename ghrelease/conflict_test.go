@@ -0,0 +1,272 @@
+package ghrelease
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdater_installFile(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        ConflictPolicy
+		local         string
+		previous      string
+		wantContent   string
+		wantConflict  bool
+		wantSavedPath string
+		wantSaved     string
+	}{
+		{
+			name:        "new file",
+			policy:      ConflictKeepLocal,
+			wantContent: "new",
+		},
+		{
+			name:        "unmodified local file",
+			policy:      ConflictKeepLocal,
+			local:       "old",
+			previous:    "old",
+			wantContent: "new",
+		},
+		{
+			name:         "untracked local file",
+			policy:       ConflictKeepLocal,
+			local:        "mine",
+			wantContent:  "mine",
+			wantConflict: true,
+		},
+		{
+			name:        "untracked local file with overwrite",
+			policy:      ConflictOverwrite,
+			local:       "mine",
+			wantContent: "new",
+		},
+		{
+			name:          "untracked local file with backup local",
+			policy:        ConflictBackupLocal,
+			local:         "mine",
+			wantContent:   "new",
+			wantConflict:  true,
+			wantSavedPath: "file.md.orig",
+			wantSaved:     "mine",
+		},
+		{
+			name:        "local already matches new",
+			policy:      ConflictKeepLocal,
+			local:       "new",
+			previous:    "old",
+			wantContent: "new",
+		},
+		{
+			name:         "modified with overwrite",
+			policy:       ConflictOverwrite,
+			local:        "mine",
+			previous:     "old",
+			wantContent:  "new",
+			wantConflict: true,
+		},
+		{
+			name:         "modified with default policy",
+			local:        "mine",
+			previous:     "old",
+			wantContent:  "new",
+			wantConflict: true,
+		},
+		{
+			name:         "modified with keep local",
+			policy:       ConflictKeepLocal,
+			local:        "mine",
+			previous:     "old",
+			wantContent:  "mine",
+			wantConflict: true,
+		},
+		{
+			name:          "modified with write new",
+			policy:        ConflictWriteNew,
+			local:         "mine",
+			previous:      "old",
+			wantContent:   "mine",
+			wantConflict:  true,
+			wantSavedPath: "file.md.new",
+			wantSaved:     "new",
+		},
+		{
+			name:          "modified with backup local",
+			policy:        ConflictBackupLocal,
+			local:         "mine",
+			previous:      "old",
+			wantContent:   "new",
+			wantConflict:  true,
+			wantSavedPath: "file.md.orig",
+			wantSaved:     "mine",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			path := filepath.Join(tmpDir, "file.md")
+			if tt.local != "" {
+				writeTestFiles(t, map[string]string{path: tt.local})
+			}
+			previousHash := ""
+			if tt.previous != "" {
+				previousHash = hashBytes([]byte(tt.previous))
+			}
+
+			target := ExtractTarget{PathTransformer: &KeepAllTransformer{}, DestDir: tmpDir, ConflictPolicy: tt.policy}
+			updater := mustNewUpdater(t, UpdaterConfig{Targets: []ExtractTarget{target}})
+
+			result := &UpdateResult{}
+			written, err := updater.installFile(target, path, []byte("new"), previousHash, result)
+			if err != nil {
+				t.Fatalf("installFile() error = %v", err)
+			}
+			if written != (tt.wantContent == "new" && tt.local != "new") {
+				t.Errorf("installFile() written = %v", written)
+			}
+
+			if (len(result.Conflicts) == 1) != tt.wantConflict {
+				t.Fatalf("installFile() conflicts = %+v, wantConflict %v", result.Conflicts, tt.wantConflict)
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", path, err)
+			}
+			if string(content) != tt.wantContent {
+				t.Errorf("content = %q, want %q", string(content), tt.wantContent)
+			}
+
+			if tt.wantSavedPath == "" {
+				return
+			}
+			savedPath := filepath.Join(tmpDir, tt.wantSavedPath)
+			if conflict := result.Conflicts[0]; conflict.SavedPath != savedPath {
+				t.Errorf("SavedPath = %q, want %q", conflict.SavedPath, savedPath)
+			}
+			saved, err := os.ReadFile(savedPath)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", savedPath, err)
+			}
+			if string(saved) != tt.wantSaved {
+				t.Errorf("saved content = %q, want %q", string(saved), tt.wantSaved)
+			}
+		})
+	}
+}
+
+func TestUpdater_applyPlan_pruneConflicts(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       ConflictPolicy
+		wantExists   bool
+		wantBackedUp bool
+	}{
+		{name: "overwrite removes modified file", policy: ConflictOverwrite},
+		{name: "keep local keeps modified file", policy: ConflictKeepLocal, wantExists: true},
+		{name: "write new keeps modified file", policy: ConflictWriteNew, wantExists: true},
+		{name: "backup local renames modified file", policy: ConflictBackupLocal, wantBackedUp: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			path := filepath.Join(tmpDir, "retired.md")
+			writeTestFiles(t, map[string]string{path: "mine"})
+
+			updater := mustNewUpdater(t, UpdaterConfig{
+				Targets: []ExtractTarget{{
+					PathTransformer: &KeepAllTransformer{},
+					DestDir:         tmpDir,
+					ConflictPolicy:  tt.policy,
+					Mirror:          true,
+				}},
+			})
+
+			_, result, err := applyTestArchive(t, updater, createTestZip(t, nil), map[string]string{path: hashBytes([]byte("old"))})
+			if err != nil {
+				t.Fatalf("applyPlan() error = %v", err)
+			}
+			if len(result.Conflicts) != 1 || !result.Conflicts[0].Removed {
+				t.Fatalf("applyPlan() conflicts = %+v, want one removal conflict", result.Conflicts)
+			}
+			if wantRemoved := !tt.wantExists; (result.Targets[0].Removed == 1) != wantRemoved {
+				t.Errorf("Removed = %d, want removed %v", result.Targets[0].Removed, wantRemoved)
+			}
+
+			if _, err := os.Stat(path); (err == nil) != tt.wantExists {
+				t.Errorf("file exists = %v, want %v", err == nil, tt.wantExists)
+			}
+			if _, err := os.Stat(path + backupFileSuffix); (err == nil) != tt.wantBackedUp {
+				t.Errorf("backup exists = %v, want %v", err == nil, tt.wantBackedUp)
+			}
+		})
+	}
+}
+
+func TestUpdater_UpdateWithResult_legacyMetadata(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       ConflictPolicy
+		wantContent  string
+		wantConflict bool
+	}{
+		{name: "overwrite replaces untracked file", policy: ConflictOverwrite, wantContent: "a2"},
+		{name: "keep local keeps untracked file", policy: ConflictKeepLocal, wantContent: "mine", wantConflict: true},
+		{name: "write new keeps untracked file", policy: ConflictWriteNew, wantContent: "mine", wantConflict: true},
+		{name: "backup local backs up untracked file", policy: ConflictBackupLocal, wantContent: "a2", wantConflict: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			destDir := filepath.Join(tmpDir, "dest")
+			metadataFile := filepath.Join(tmpDir, "metadata.json")
+			writeTestFiles(t, map[string]string{
+				metadataFile:                   `{"version":"v1","last_check_at":""}`,
+				filepath.Join(destDir, "a.md"): "mine",
+				filepath.Join(destDir, "b.md"): "b2",
+			})
+
+			source := &fakeSource{t: t, latest: "v2", releases: map[string]map[string]string{
+				"v2": {"a.md": "a2", "b.md": "b2"},
+			}}
+			updater := mustNewUpdater(t, UpdaterConfig{
+				Source:       source,
+				MetadataFile: metadataFile,
+				Targets:      []ExtractTarget{{PathTransformer: &KeepAllTransformer{}, DestDir: destDir, ConflictPolicy: tt.policy}},
+			})
+
+			result, err := updater.UpdateWithResult(context.Background())
+			if err != nil {
+				t.Fatalf("UpdateWithResult() error = %v", err)
+			}
+			if (len(result.Conflicts) == 1) != tt.wantConflict {
+				t.Fatalf("UpdateWithResult() conflicts = %+v, wantConflict %v", result.Conflicts, tt.wantConflict)
+			}
+
+			content, err := os.ReadFile(filepath.Join(destDir, "a.md"))
+			if err != nil || string(content) != tt.wantContent {
+				t.Errorf("a.md = %q, %v, want %q", content, err, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestNewUpdater_invalidConflictPolicy(t *testing.T) {
+	_, err := NewUpdater(UpdaterConfig{
+		RepoOwner: "owner",
+		RepoName:  "repo",
+		Targets: []ExtractTarget{{
+			PathTransformer: &KeepAllTransformer{},
+			DestDir:         "/tmp",
+			ConflictPolicy:  "unknown",
+		}},
+	})
+	if err == nil {
+		t.Fatal("NewUpdater() should return error for invalid conflict policy")
+	}
+}
@@ -0,0 +1,192 @@
+package ghrelease
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func isBinary(content []byte) bool {
+	n := len(content)
+	if n > 8000 {
+		n = 8000
+	}
+	return bytes.IndexByte(content[:n], 0) != -1
+}
+
+func matchLines(a, b []string) []int {
+	match := make([]int, len(a))
+	for i := range match {
+		match[i] = -1
+	}
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		match[prefix] = prefix
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		match[len(a)-1-suffix] = len(b) - 1 - suffix
+		suffix++
+	}
+
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+	if len(midA) == 0 || len(midB) == 0 {
+		return match
+	}
+
+	lcs := make([][]int, len(midA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(midA) && j < len(midB) {
+		switch {
+		case midA[i] == midB[j]:
+			match[prefix+i] = prefix + j
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	return match
+}
+
+const diffContextLines = 3
+
+type diffLine struct {
+	kind byte
+	text string
+}
+
+func diffScript(a, b []string) []diffLine {
+	match := matchLines(a, b)
+
+	var script []diffLine
+	j := 0
+	for i, line := range a {
+		if match[i] < 0 {
+			script = append(script, diffLine{kind: '-', text: line})
+			continue
+		}
+		for ; j < match[i]; j++ {
+			script = append(script, diffLine{kind: '+', text: b[j]})
+		}
+		script = append(script, diffLine{kind: ' ', text: line})
+		j++
+	}
+	for ; j < len(b); j++ {
+		script = append(script, diffLine{kind: '+', text: b[j]})
+	}
+	return script
+}
+
+func unifiedDiff(fromName, toName string, a, b []byte) (string, int, int) {
+	script := diffScript(splitLines(a), splitLines(b))
+
+	additions, deletions := 0, 0
+	var changes []int
+	for i, line := range script {
+		switch line.kind {
+		case '+':
+			additions++
+			changes = append(changes, i)
+		case '-':
+			deletions++
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return "", 0, 0
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+
+	for start := 0; start < len(changes); {
+		end := start
+		for end+1 < len(changes) && changes[end+1]-changes[end] <= 2*diffContextLines {
+			end++
+		}
+
+		first := max(changes[start]-diffContextLines, 0)
+		last := min(changes[end]+diffContextLines, len(script)-1)
+
+		aStart, bStart := 1, 1
+		for _, line := range script[:first] {
+			if line.kind != '+' {
+				aStart++
+			}
+			if line.kind != '-' {
+				bStart++
+			}
+		}
+
+		aLen, bLen := 0, 0
+		for _, line := range script[first : last+1] {
+			if line.kind != '+' {
+				aLen++
+			}
+			if line.kind != '-' {
+				bLen++
+			}
+		}
+		if aLen == 0 {
+			aStart--
+		}
+		if bLen == 0 {
+			bStart--
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aStart, aLen), hunkRange(bStart, bLen))
+		for _, line := range script[first : last+1] {
+			out.WriteByte(line.kind)
+			out.WriteString(line.text)
+			if !strings.HasSuffix(line.text, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		start = end + 1
+	}
+
+	return out.String(), additions, deletions
+}
+
+func hunkRange(start, length int) string {
+	if length == 1 {
+		return strconv.Itoa(start)
+	}
+	return fmt.Sprintf("%d,%d", start, length)
+}
@@ -0,0 +1,124 @@
+package ghrelease
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{name: "empty", content: "", want: nil},
+		{name: "trailing newline", content: "a\nb\n", want: []string{"a\n", "b\n"}},
+		{name: "no trailing newline", content: "a\nb", want: []string{"a\n", "b"}},
+		{name: "blank lines", content: "\n\n", want: []string{"\n", "\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitLines([]byte(tt.content)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitLines(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a    []string
+		b    []string
+		want []int
+	}{
+		{name: "identical", a: []string{"x", "y"}, b: []string{"x", "y"}, want: []int{0, 1}},
+		{name: "insertion", a: []string{"x", "z"}, b: []string{"x", "y", "z"}, want: []int{0, 2}},
+		{name: "deletion", a: []string{"x", "y", "z"}, b: []string{"x", "z"}, want: []int{0, -1, 1}},
+		{name: "replacement", a: []string{"x", "y", "z"}, b: []string{"x", "q", "z"}, want: []int{0, -1, 2}},
+		{name: "empty b", a: []string{"x"}, b: nil, want: []int{-1}},
+		{name: "reordered", a: []string{"p", "a", "b", "q"}, b: []string{"r", "b", "a", "s"}, want: []int{-1, -1, 1, -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchLines(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matchLines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	if isBinary([]byte("plain text\n")) {
+		t.Error("isBinary(text) = true, want false")
+	}
+	if !isBinary([]byte{'a', 0, 'b'}) {
+		t.Error("isBinary(nul) = false, want true")
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name          string
+		a             string
+		b             string
+		want          string
+		wantAdditions int
+		wantDeletions int
+	}{
+		{
+			name: "identical",
+			a:    "a\nb\n",
+			b:    "a\nb\n",
+			want: "",
+		},
+		{
+			name: "single change",
+			a:    "a\nb\nc\n",
+			b:    "a\nB\nc\n",
+			want: "--- a/f\n+++ b/f\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+
+			wantAdditions: 1,
+			wantDeletions: 1,
+		},
+		{
+			name: "new file",
+			a:    "",
+			b:    "x\n",
+			want: "--- a/f\n+++ b/f\n@@ -0,0 +1 @@\n+x\n",
+
+			wantAdditions: 1,
+		},
+		{
+			name: "separate hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			b:    "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			want: "--- a/f\n+++ b/f\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+
+			wantAdditions: 2,
+			wantDeletions: 2,
+		},
+		{
+			name: "missing trailing newline",
+			a:    "a\n",
+			b:    "a\nb",
+			want: "--- a/f\n+++ b/f\n@@ -1 +1,2 @@\n a\n+b\n\\ No newline at end of file\n",
+
+			wantAdditions: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, additions, deletions := unifiedDiff("a/f", "b/f", []byte(tt.a), []byte(tt.b))
+			if got != tt.want {
+				t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if additions != tt.wantAdditions || deletions != tt.wantDeletions {
+				t.Errorf("unifiedDiff() stats = +%d -%d, want +%d -%d", additions, deletions, tt.wantAdditions, tt.wantDeletions)
+			}
+		})
+	}
+}
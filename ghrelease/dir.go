@@ -0,0 +1,215 @@
+package ghrelease
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+func NewDirSource(dir string) *DirSource {
+	return &DirSource{Dir: dir}
+}
+
+func (s *DirSource) ListReleases(ctx context.Context) ([]*Release, error) {
+	if s.Dir == "" {
+		return nil, fmt.Errorf("release dir cannot be empty")
+	}
+
+	dirEntries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list releases: %w", err)
+	}
+
+	var releases []*Release
+	seen := make(map[string]bool)
+	for _, dirEntry := range dirEntries {
+		release, err := s.newRelease(dirEntry)
+		if err != nil {
+			return nil, err
+		}
+		if release == nil || seen[release.Tag] {
+			continue
+		}
+		seen[release.Tag] = true
+		releases = append(releases, release)
+	}
+
+	sort.SliceStable(releases, func(i, j int) bool {
+		return CompareVersions(releases[i].Tag, releases[j].Tag) > 0
+	})
+	return releases, nil
+}
+
+func (s *DirSource) LatestRelease(ctx context.Context) (*Release, error) {
+	releases, err := s.ListReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return latestStable(releases, s.Dir)
+}
+
+func (s *DirSource) ReleaseByTag(ctx context.Context, tag string) (*Release, error) {
+	releases, err := s.ListReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return findRelease(releases, tag, s.Dir)
+}
+
+func (s *DirSource) OpenArchive(ctx context.Context, release *Release) (io.ReadCloser, error) {
+	if release.ArchiveURL == "" {
+		return nil, fmt.Errorf("release %s has no archive url", release.Tag)
+	}
+
+	path, err := localPath(release.ArchiveURL)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		data, err := zipDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack %s: %w", path, err)
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	flat, err := isFlatArchive(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if flat {
+		if data, err = reshapeArchive(data, true, false); err != nil {
+			return nil, err
+		}
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *DirSource) OpenAsset(ctx context.Context, asset Asset) (io.ReadCloser, error) {
+	if asset.DownloadURL == "" {
+		return nil, fmt.Errorf("asset %s has no download url", asset.Name)
+	}
+	path, err := localPath(asset.DownloadURL)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (s *DirSource) newRelease(dirEntry fs.DirEntry) (*Release, error) {
+	name := dirEntry.Name()
+	base := name
+	if !dirEntry.IsDir() {
+		var ok bool
+		if base, ok = trimArchiveExt(name); !ok {
+			return nil, nil
+		}
+	}
+	version := manifestVersionPattern.FindString(base)
+	if version == "" {
+		return nil, nil
+	}
+
+	info, err := dirEntry.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(s.Dir, name)
+	return &Release{
+		Tag:         version,
+		Name:        name,
+		PublishedAt: info.ModTime(),
+		Prerelease:  isPrerelease(version),
+		ArchiveURL:  fileURL(path),
+		FlatArchive: dirEntry.IsDir(),
+	}, nil
+}
+
+func latestStable(releases []*Release, location string) (*Release, error) {
+	for _, release := range releases {
+		if !release.Prerelease {
+			return release, nil
+		}
+	}
+	return nil, fmt.Errorf("no releases found in %s", location)
+}
+
+func findRelease(releases []*Release, tag, location string) (*Release, error) {
+	for _, release := range releases {
+		if release.Tag == tag {
+			return release, nil
+		}
+	}
+	return nil, fmt.Errorf("release %s not found in %s", tag, location)
+}
+
+func localPath(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("not a file url: %s", rawURL)
+	}
+	return filepath.FromSlash(u.Path), nil
+}
+
+func zipDir(dir string) ([]byte, error) {
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)], err = os.ReadFile(path)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return zipFiles(files)
+}
+
+func zipFiles(files map[string][]byte) ([]byte, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	for _, name := range names {
+		f, err := w.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
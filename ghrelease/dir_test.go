@@ -0,0 +1,197 @@
+package ghrelease
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newTestReleaseDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeTestFiles(t, map[string]string{
+		filepath.Join(dir, "v1.1.0", "README.md"):   "readme",
+		filepath.Join(dir, "v1.1.0", "agents/a.md"): "a2",
+		filepath.Join(dir, "v1.1.0", "agents/b.md"): "b2",
+	})
+	archives := map[string][]byte{
+		"pack-v1.0.0.zip":        createTestZip(t, map[string]string{"pack/agents/a.md": "a1", "pack/agents/old.md": "old"}),
+		"pack-v1.2.0-rc1.tar.gz": createTestTarGz(t, map[string]string{"pack/agents/a.md": "a3"}),
+		"pack-v0.9.0.zip":        createTestZip(t, map[string]string{"agents/a.md": "a0", "README.md": "readme"}),
+		"notes.txt":              []byte("ignored"),
+	}
+	for name, data := range archives {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "scratch"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestDirSource(t *testing.T) {
+	dir := newTestReleaseDir(t)
+	source := NewDirSource(dir)
+	ctx := context.Background()
+
+	releases, err := source.ListReleases(ctx)
+	if err != nil {
+		t.Fatalf("ListReleases() error = %v", err)
+	}
+	var tags []string
+	for _, release := range releases {
+		tags = append(tags, release.Tag)
+	}
+	if want := []string{"v1.2.0-rc1", "v1.1.0", "v1.0.0", "v0.9.0"}; !reflect.DeepEqual(tags, want) {
+		t.Fatalf("ListReleases() tags = %v, want %v", tags, want)
+	}
+	if !releases[0].Prerelease || releases[1].Prerelease {
+		t.Errorf("prerelease = %v, %v", releases[0].Prerelease, releases[1].Prerelease)
+	}
+	if !releases[1].FlatArchive || releases[2].FlatArchive || releases[3].FlatArchive {
+		t.Errorf("flat = %v, %v, %v", releases[1].FlatArchive, releases[2].FlatArchive, releases[3].FlatArchive)
+	}
+
+	latest, err := source.LatestRelease(ctx)
+	if err != nil {
+		t.Fatalf("LatestRelease() error = %v", err)
+	}
+	if latest.Tag != "v1.1.0" || latest.ArchiveURL != fileURL(filepath.Join(dir, "v1.1.0")) {
+		t.Errorf("LatestRelease() = %+v", latest)
+	}
+
+	archivePaths := func(release *Release) []string {
+		t.Helper()
+		rc, err := source.OpenArchive(ctx, release)
+		if err != nil {
+			t.Fatalf("OpenArchive() error = %v", err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read archive: %v", err)
+		}
+		entries, err := (&Updater{}).readArchive(release, data)
+		if err != nil {
+			t.Fatalf("readArchive() error = %v", err)
+		}
+		var paths []string
+		for _, entry := range entries {
+			paths = append(paths, entry.path)
+		}
+		return paths
+	}
+	if paths, want := archivePaths(latest), []string{"README.md", "agents/a.md", "agents/b.md"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("archive paths = %v, want %v", paths, want)
+	}
+	if paths, want := archivePaths(releases[3]), []string{"README.md", "agents/a.md"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("flat archive paths = %v, want %v", paths, want)
+	}
+
+	if _, err := source.ReleaseByTag(ctx, "v1.0.0"); err != nil {
+		t.Errorf("ReleaseByTag() error = %v", err)
+	}
+}
+
+func TestDirSource_errors(t *testing.T) {
+	empty := t.TempDir()
+	corrupt := t.TempDir()
+	if err := os.WriteFile(filepath.Join(corrupt, "pack-v1.0.0.zip"), []byte("not a zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		source *DirSource
+		tag    string
+		open   bool
+	}{
+		{name: "empty dir path", source: &DirSource{}},
+		{name: "missing dir", source: NewDirSource(filepath.Join(empty, "missing"))},
+		{name: "no releases", source: NewDirSource(empty)},
+		{name: "corrupt archive", source: NewDirSource(corrupt), open: true},
+		{name: "unknown tag", source: NewDirSource(newTestReleaseDir(t)), tag: "v9.9.9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			var release *Release
+			var err error
+			if tt.tag != "" {
+				release, err = tt.source.ReleaseByTag(ctx, tt.tag)
+			} else {
+				release, err = tt.source.LatestRelease(ctx)
+			}
+			if tt.open {
+				if err != nil {
+					t.Fatalf("LatestRelease() error = %v", err)
+				}
+				_, err = tt.source.OpenArchive(ctx, release)
+			}
+			if err == nil {
+				t.Error("should fail")
+			}
+		})
+	}
+}
+
+func TestUpdater_dirSource(t *testing.T) {
+	releaseDir := newTestReleaseDir(t)
+	stagedDir := filepath.Join(releaseDir, "v1.1.0")
+	hiddenDir := filepath.Join(t.TempDir(), "v1.1.0")
+	if err := os.Rename(stagedDir, hiddenDir); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpDir := t.TempDir()
+	agentsDir := filepath.Join(tmpDir, "agents")
+	updater := mustNewUpdater(t, UpdaterConfig{
+		Source:       NewDirSource(releaseDir),
+		MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+		Targets:      []ExtractTarget{{PathTransformer: &SubDirTransformer{SubDir: "agents"}, DestDir: agentsDir}},
+	})
+	ctx := context.Background()
+
+	result, err := updater.UpdateWithResult(ctx)
+	if err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	if result.Version != "v1.0.0" || result.Targets[0].Written != 2 {
+		t.Errorf("first update = %+v", result)
+	}
+
+	if err := os.Rename(hiddenDir, stagedDir); err != nil {
+		t.Fatal(err)
+	}
+	result, err = updater.UpdateWithResult(ctx)
+	if err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	if result.PreviousVersion != "v1.0.0" || result.Version != "v1.1.0" || result.Targets[0].Removed != 1 {
+		t.Errorf("second update = %+v", result)
+	}
+	for name, want := range map[string]string{"a.md": "a2", "b.md": "b2"} {
+		content, err := os.ReadFile(filepath.Join(agentsDir, name))
+		if err != nil || string(content) != want {
+			t.Errorf("%s = %q, %v, want %q", name, content, err, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(agentsDir, "old.md")); !os.IsNotExist(err) {
+		t.Error("old.md should be pruned")
+	}
+
+	result, err = updater.UpdateWithResult(ctx)
+	if err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	if result.Changed() || result.Downloaded {
+		t.Errorf("third update = %+v, want up to date", result)
+	}
+}
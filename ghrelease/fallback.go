@@ -0,0 +1,340 @@
+package ghrelease
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const defaultSourceCooldown = time.Minute
+
+var ErrSourcesDisagree = errors.New("sources disagree on checksum")
+
+func (u *Updater) SourceHealth() []SourceHealth {
+	if fallback, ok := u.source.(*FallbackSource); ok {
+		return fallback.Health()
+	}
+	return nil
+}
+
+func NewFallbackSource(sources ...Source) *FallbackSource {
+	return &FallbackSource{Sources: sources}
+}
+
+func (s *FallbackSource) ListReleases(ctx context.Context) ([]*Release, error) {
+	var releases []*Release
+	err := s.try(ctx, func(i int, source Source) error {
+		var err error
+		releases, err = source.ListReleases(ctx)
+		return err
+	})
+	return releases, err
+}
+
+func (s *FallbackSource) LatestRelease(ctx context.Context) (*Release, error) {
+	var release *Release
+	index := -1
+	err := s.try(ctx, func(i int, source Source) error {
+		var err error
+		release, err = source.LatestRelease(ctx)
+		index = i
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return release, s.crossCheck(ctx, index, release)
+}
+
+func (s *FallbackSource) ReleaseByTag(ctx context.Context, tag string) (*Release, error) {
+	var release *Release
+	index := -1
+	err := s.try(ctx, func(i int, source Source) error {
+		var err error
+		release, err = source.ReleaseByTag(ctx, tag)
+		index = i
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return release, s.crossCheck(ctx, index, release)
+}
+
+func (s *FallbackSource) OpenArchive(ctx context.Context, release *Release) (io.ReadCloser, error) {
+	var data []byte
+	err := s.try(ctx, func(i int, source Source) error {
+		own, err := s.releaseFrom(ctx, i, release.Tag)
+		if err != nil {
+			return err
+		}
+		if !sameChecksum(release.ArchiveSHA256, own.ArchiveSHA256) {
+			return fmt.Errorf("%w: archive for %s: %s vs %s", ErrSourcesDisagree, release.Tag, release.ArchiveSHA256, own.ArchiveSHA256)
+		}
+
+		rc, err := source.OpenArchive(ctx, own)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		if data, err = io.ReadAll(rc); err != nil {
+			return err
+		}
+		if own.ArchiveSHA256 != "" && !strings.EqualFold(hashBytes(data), own.ArchiveSHA256) {
+			return fmt.Errorf("archive checksum mismatch for %s", release.Tag)
+		}
+		if own.FlatArchive != release.FlatArchive {
+			data, err = reshapeArchive(data, own.FlatArchive, release.FlatArchive)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *FallbackSource) OpenAsset(ctx context.Context, asset Asset) (io.ReadCloser, error) {
+	tag, owner := s.assetOwner(asset)
+
+	var data []byte
+	err := s.try(ctx, func(i int, source Source) error {
+		candidate := asset
+		if owner >= 0 && i != owner {
+			own, err := s.releaseFrom(ctx, i, tag)
+			if err != nil {
+				return err
+			}
+			var ok bool
+			if candidate, ok = findAsset(own, asset.Name); !ok {
+				return fmt.Errorf("release %s has no asset %s", tag, asset.Name)
+			}
+			if !sameChecksum(asset.SHA256, candidate.SHA256) {
+				return fmt.Errorf("%w: asset %s: %s vs %s", ErrSourcesDisagree, asset.Name, asset.SHA256, candidate.SHA256)
+			}
+		}
+
+		rc, err := source.OpenAsset(ctx, candidate)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		if data, err = io.ReadAll(rc); err != nil {
+			return err
+		}
+		if candidate.SHA256 != "" && !strings.EqualFold(hashBytes(data), candidate.SHA256) {
+			return fmt.Errorf("checksum mismatch for %s", asset.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *FallbackSource) Health() []SourceHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.initHealth()
+	health := make([]SourceHealth, len(s.health))
+	for i, h := range s.health {
+		h.Healthy = s.healthy(i)
+		health[i] = h
+	}
+	return health
+}
+
+func (s *FallbackSource) try(ctx context.Context, fn func(i int, source Source) error) error {
+	if len(s.Sources) == 0 {
+		return fmt.Errorf("no sources configured")
+	}
+
+	var errs []error
+	for _, i := range s.order() {
+		err := fn(i, s.Sources[i])
+		if err == nil {
+			s.recordSuccess(i)
+			return nil
+		}
+		if errors.Is(err, ErrSourcesDisagree) {
+			return err
+		}
+		errs = append(errs, fmt.Errorf("source %d: %w", i, err))
+		if ctx.Err() != nil {
+			break
+		}
+		s.recordFailure(i, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (s *FallbackSource) crossCheck(ctx context.Context, index int, release *Release) error {
+	releases := make([]*Release, len(s.Sources))
+	releases[index] = release
+
+	for _, i := range s.order() {
+		if i == index || !s.isHealthy(i) {
+			continue
+		}
+		other, err := s.Sources[i].ReleaseByTag(ctx, release.Tag)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.recordFailure(i, err)
+			continue
+		}
+		if !sameChecksum(release.ArchiveSHA256, other.ArchiveSHA256) {
+			return fmt.Errorf("%w: archive for %s: source %d has %s, source %d has %s",
+				ErrSourcesDisagree, release.Tag, index, release.ArchiveSHA256, i, other.ArchiveSHA256)
+		}
+		for _, asset := range release.Assets {
+			if match, ok := findAsset(other, asset.Name); ok && !sameChecksum(asset.SHA256, match.SHA256) {
+				return fmt.Errorf("%w: asset %s in %s: source %d has %s, source %d has %s",
+					ErrSourcesDisagree, asset.Name, release.Tag, index, asset.SHA256, i, match.SHA256)
+			}
+		}
+		releases[i] = other
+	}
+
+	s.mu.Lock()
+	s.cachedTag = release.Tag
+	s.cachedReleases = releases
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *FallbackSource) releaseFrom(ctx context.Context, i int, tag string) (*Release, error) {
+	s.mu.Lock()
+	if s.cachedTag == tag && i < len(s.cachedReleases) && s.cachedReleases[i] != nil {
+		release := s.cachedReleases[i]
+		s.mu.Unlock()
+		return release, nil
+	}
+	s.mu.Unlock()
+
+	return s.Sources[i].ReleaseByTag(ctx, tag)
+}
+
+func (s *FallbackSource) assetOwner(asset Asset) (string, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, release := range s.cachedReleases {
+		if release == nil {
+			continue
+		}
+		for _, a := range release.Assets {
+			if a.DownloadURL == asset.DownloadURL {
+				return s.cachedTag, i
+			}
+		}
+	}
+	return "", -1
+}
+
+func (s *FallbackSource) order() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.initHealth()
+	var healthy, unhealthy []int
+	for i := range s.Sources {
+		if s.healthy(i) {
+			healthy = append(healthy, i)
+		} else {
+			unhealthy = append(unhealthy, i)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+func (s *FallbackSource) isHealthy(i int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.initHealth()
+	return s.healthy(i)
+}
+
+func (s *FallbackSource) healthy(i int) bool {
+	h := s.health[i]
+	return h.Failures == 0 || time.Since(h.LastFailure) >= s.cooldown()
+}
+
+func (s *FallbackSource) recordSuccess(i int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.initHealth()
+	s.health[i].Failures = 0
+	s.health[i].LastSuccess = time.Now()
+}
+
+func (s *FallbackSource) recordFailure(i int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.initHealth()
+	s.health[i].Failures++
+	s.health[i].LastError = err.Error()
+	s.health[i].LastFailure = time.Now()
+}
+
+func (s *FallbackSource) initHealth() {
+	if len(s.health) == len(s.Sources) {
+		return
+	}
+	s.health = make([]SourceHealth, len(s.Sources))
+	s.cachedTag, s.cachedReleases = "", nil
+	for i, source := range s.Sources {
+		s.health[i].Source = source
+	}
+}
+
+func (s *FallbackSource) cooldown() time.Duration {
+	if s.Cooldown == 0 {
+		return defaultSourceCooldown
+	}
+	return s.Cooldown
+}
+
+func sameChecksum(a, b string) bool {
+	return a == "" || b == "" || strings.EqualFold(a, b)
+}
+
+func findAsset(release *Release, name string) (Asset, bool) {
+	for _, asset := range release.Assets {
+		if asset.Name == name {
+			return asset, true
+		}
+	}
+	return Asset{}, false
+}
+
+func reshapeArchive(data []byte, fromFlat, toFlat bool) ([]byte, error) {
+	entries, err := archiveEntries(data, fromFlat)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte, len(entries))
+	for _, entry := range entries {
+		content, err := entry.read()
+		if err != nil {
+			return nil, err
+		}
+		name := entry.path
+		if !toFlat {
+			name = syntheticRootDir + name
+		}
+		files[name] = content
+	}
+	return zipFiles(files)
+}
@@ -0,0 +1,331 @@
+package ghrelease
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type brokenSource struct {
+	Source
+
+	mu          sync.Mutex
+	failResolve bool
+	failArchive bool
+	calls       int
+}
+
+func (s *brokenSource) set(failResolve, failArchive bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failResolve, s.failArchive = failResolve, failArchive
+}
+
+func (s *brokenSource) check(archive bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.failResolve || (archive && s.failArchive) {
+		return fmt.Errorf("connection refused")
+	}
+	return nil
+}
+
+func (s *brokenSource) callCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func (s *brokenSource) LatestRelease(ctx context.Context) (*Release, error) {
+	if err := s.check(false); err != nil {
+		return nil, err
+	}
+	return s.Source.LatestRelease(ctx)
+}
+
+func (s *brokenSource) ReleaseByTag(ctx context.Context, tag string) (*Release, error) {
+	if err := s.check(false); err != nil {
+		return nil, err
+	}
+	return s.Source.ReleaseByTag(ctx, tag)
+}
+
+func (s *brokenSource) OpenArchive(ctx context.Context, release *Release) (io.ReadCloser, error) {
+	if err := s.check(true); err != nil {
+		return nil, err
+	}
+	return s.Source.OpenArchive(ctx, release)
+}
+
+func (s *brokenSource) OpenAsset(ctx context.Context, asset Asset) (io.ReadCloser, error) {
+	if err := s.check(true); err != nil {
+		return nil, err
+	}
+	return s.Source.OpenAsset(ctx, asset)
+}
+
+func newFallbackUpdater(t *testing.T, sources ...Source) (*Updater, string) {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	agentsDir := filepath.Join(tmpDir, "agents")
+	return mustNewUpdater(t, UpdaterConfig{
+		Sources:      sources,
+		MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+		Targets:      []ExtractTarget{{PathTransformer: &SubDirTransformer{SubDir: "agents"}, DestDir: agentsDir}},
+	}), agentsDir
+}
+
+func newTestMirrorDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	rooted := make(map[string]string, len(files))
+	for name, content := range files {
+		rooted[filepath.Join(dir, "v1.1.0", name)] = content
+	}
+	writeTestFiles(t, rooted)
+	return dir
+}
+
+func assertFiles(t *testing.T, dir string, want map[string]string) {
+	t.Helper()
+	for name, content := range want {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(got) != content {
+			t.Errorf("%s = %q, %v, want %q", name, got, err, content)
+		}
+	}
+}
+
+func TestFallbackSource_resolveFallback(t *testing.T) {
+	primary := &brokenSource{Source: &fakeSource{t: t, latest: "v1.1.0", releases: map[string]map[string]string{
+		"v1.1.0": {"agents/a.md": "primary"},
+	}}}
+	primary.set(true, false)
+	mirror := NewDirSource(newTestMirrorDir(t, map[string]string{"agents/a.md": "mirror"}))
+
+	updater, agentsDir := newFallbackUpdater(t, primary, mirror)
+	result, err := updater.UpdateWithResult(context.Background())
+	if err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	if result.Version != "v1.1.0" {
+		t.Errorf("Version = %s, want v1.1.0", result.Version)
+	}
+	assertFiles(t, agentsDir, map[string]string{"a.md": "mirror"})
+
+	health := updater.SourceHealth()
+	if len(health) != 2 || health[0].Healthy || health[0].Failures != 1 || !strings.Contains(health[0].LastError, "connection refused") {
+		t.Errorf("primary health = %+v", health[0])
+	}
+	if !health[1].Healthy || health[1].LastSuccess.IsZero() {
+		t.Errorf("mirror health = %+v", health[1])
+	}
+
+	calls := primary.callCount()
+	if _, err := updater.source.LatestRelease(context.Background()); err != nil {
+		t.Fatalf("LatestRelease() error = %v", err)
+	}
+	if got := primary.callCount(); got != calls {
+		t.Errorf("unhealthy primary should be skipped while cooling down, got %d calls", got-calls)
+	}
+}
+
+func TestFallbackSource_downloadFallback(t *testing.T) {
+	primary := &brokenSource{Source: &fakeSource{t: t, latest: "v1.1.0", releases: map[string]map[string]string{
+		"v1.1.0": {"agents/a.md": "primary"},
+	}}}
+	primary.set(false, true)
+	mirror := NewDirSource(newTestMirrorDir(t, map[string]string{"agents/a.md": "mirror", "agents/b.md": "b"}))
+
+	updater, agentsDir := newFallbackUpdater(t, primary, mirror)
+	if _, err := updater.UpdateWithResult(context.Background()); err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	assertFiles(t, agentsDir, map[string]string{"a.md": "mirror", "b.md": "b"})
+}
+
+func TestFallbackSource_primaryChecksumMirrorDownload(t *testing.T) {
+	archive := createTestTarGz(t, map[string]string{"pack/agents/a.md": "primary"})
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "pack.tar.gz"), archive, 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeTestManifest(t, dir, &Manifest{Releases: []ManifestRelease{{
+		Version: "v1.1.0",
+		Archive: ManifestFile{URL: "pack.tar.gz", SHA256: hashBytes(archive)},
+	}}})
+	primary := &brokenSource{Source: NewManifestSource(fileURL(filepath.Join(dir, "manifest.json")))}
+	primary.set(false, true)
+	mirror := NewDirSource(newTestMirrorDir(t, map[string]string{"agents/a.md": "mirror"}))
+
+	updater, agentsDir := newFallbackUpdater(t, primary, mirror)
+	if _, err := updater.UpdateWithResult(context.Background()); err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	assertFiles(t, agentsDir, map[string]string{"a.md": "mirror"})
+}
+
+func TestFallbackSource_crossCheckFailure(t *testing.T) {
+	primary := &fakeSource{t: t, latest: "v1", releases: map[string]map[string]string{"v1": {}}}
+	mirror := &brokenSource{Source: &fakeSource{t: t, latest: "v1", releases: map[string]map[string]string{"v1": {}}}}
+	mirror.set(true, true)
+	source := NewFallbackSource(primary, mirror)
+	ctx := context.Background()
+
+	if _, err := source.LatestRelease(ctx); err != nil {
+		t.Fatalf("LatestRelease() error = %v", err)
+	}
+	if health := source.Health()[1]; health.Healthy || health.Failures != 1 {
+		t.Errorf("mirror health = %+v, want one failure while cooling down", health)
+	}
+
+	calls := mirror.callCount()
+	if _, err := source.LatestRelease(ctx); err != nil {
+		t.Fatalf("LatestRelease() error = %v", err)
+	}
+	if got := mirror.callCount(); got != calls {
+		t.Errorf("unhealthy mirror should not be cross-checked while cooling down, got %d calls", got-calls)
+	}
+}
+
+func TestFallbackSource_checksums(t *testing.T) {
+	archive := createTestTarGz(t, map[string]string{"pack/agents/a.md": "a"})
+	tampered := createTestTarGz(t, map[string]string{"pack/agents/a.md": "evil"})
+
+	newManifest := func(t *testing.T, data []byte, sha string) *ManifestSource {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "pack.tar.gz"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		writeTestManifest(t, dir, &Manifest{Releases: []ManifestRelease{{
+			Version: "v1.0.0",
+			Archive: ManifestFile{URL: "pack.tar.gz", SHA256: sha},
+			Assets:  []ManifestAsset{{Name: "pack.tar.gz", URL: "pack.tar.gz", SHA256: sha}},
+		}}})
+		return NewManifestSource(fileURL(filepath.Join(dir, "manifest.json")))
+	}
+
+	t.Run("agreeing sources", func(t *testing.T) {
+		updater, agentsDir := newFallbackUpdater(t, newManifest(t, archive, hashBytes(archive)), newManifest(t, archive, hashBytes(archive)))
+		if _, err := updater.UpdateWithResult(context.Background()); err != nil {
+			t.Fatalf("UpdateWithResult() error = %v", err)
+		}
+		assertFiles(t, agentsDir, map[string]string{"a.md": "a"})
+	})
+
+	t.Run("disagreeing sources", func(t *testing.T) {
+		updater, agentsDir := newFallbackUpdater(t, newManifest(t, archive, hashBytes(archive)), newManifest(t, tampered, hashBytes(tampered)))
+		_, err := updater.UpdateWithResult(context.Background())
+		if !errors.Is(err, ErrSourcesDisagree) {
+			t.Fatalf("UpdateWithResult() error = %v, want ErrSourcesDisagree", err)
+		}
+		if _, err := os.Stat(agentsDir); !os.IsNotExist(err) {
+			t.Error("nothing should be installed when sources disagree")
+		}
+	})
+
+	t.Run("corrupt primary download", func(t *testing.T) {
+		source := NewFallbackSource(newManifest(t, tampered, hashBytes(archive)), newManifest(t, archive, hashBytes(archive)))
+		release, err := source.LatestRelease(context.Background())
+		if err != nil {
+			t.Fatalf("LatestRelease() error = %v", err)
+		}
+
+		rc, err := source.OpenArchive(context.Background(), release)
+		if err != nil {
+			t.Fatalf("OpenArchive() error = %v", err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if hashBytes(data) != hashBytes(archive) {
+			t.Error("OpenArchive() should fall through to the intact mirror")
+		}
+
+		rc, err = source.OpenAsset(context.Background(), release.Assets[0])
+		if err != nil {
+			t.Fatalf("OpenAsset() error = %v", err)
+		}
+		data, _ = io.ReadAll(rc)
+		rc.Close()
+		if hashBytes(data) != hashBytes(archive) {
+			t.Error("OpenAsset() should fall through to the intact mirror")
+		}
+
+		if health := source.Health(); health[0].Failures != 1 || health[0].Healthy {
+			t.Errorf("primary health = %+v, want one failure while cooling down", health[0])
+		}
+	})
+}
+
+func TestFallbackSource_cooldown(t *testing.T) {
+	primary := &brokenSource{Source: &fakeSource{t: t, latest: "v1", releases: map[string]map[string]string{"v1": {}}}}
+	mirror := &fakeSource{t: t, latest: "v1", releases: map[string]map[string]string{"v1": {}}}
+	source := NewFallbackSource(primary, mirror)
+	source.Cooldown = 20 * time.Millisecond
+	ctx := context.Background()
+
+	primary.set(true, true)
+	if _, err := source.LatestRelease(ctx); err != nil {
+		t.Fatalf("LatestRelease() error = %v", err)
+	}
+	if source.Health()[0].Healthy {
+		t.Fatal("primary should be unhealthy after a failure")
+	}
+
+	primary.set(false, false)
+	time.Sleep(30 * time.Millisecond)
+	if !source.Health()[0].Healthy {
+		t.Fatal("primary should be healthy again after the cooldown")
+	}
+	calls := primary.callCount()
+	if _, err := source.LatestRelease(ctx); err != nil {
+		t.Fatalf("LatestRelease() error = %v", err)
+	}
+	if primary.callCount() == calls {
+		t.Error("primary should be tried first again after the cooldown")
+	}
+	if health := source.Health()[0]; health.Failures != 0 || !health.Healthy {
+		t.Errorf("primary health = %+v", health)
+	}
+}
+
+func TestFallbackSource_errors(t *testing.T) {
+	primary := &brokenSource{Source: &fakeSource{t: t, releases: map[string]map[string]string{}}}
+	primary.set(true, true)
+
+	tests := []struct {
+		name    string
+		source  *FallbackSource
+		wantErr string
+	}{
+		{name: "no sources", source: NewFallbackSource(), wantErr: "no sources configured"},
+		{name: "all sources fail", source: NewFallbackSource(primary, &fakeSource{t: t, releases: map[string]map[string]string{}}), wantErr: "source 1: release  not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.source.LatestRelease(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LatestRelease() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := NewUpdater(UpdaterConfig{
+		Source:  primary,
+		Sources: []Source{primary},
+		Targets: []ExtractTarget{{PathTransformer: &KeepAllTransformer{}, DestDir: t.TempDir()}},
+	}); err == nil {
+		t.Error("NewUpdater() with both Source and Sources should fail")
+	}
+}
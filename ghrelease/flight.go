@@ -0,0 +1,20 @@
+package ghrelease
+
+import (
+	"context"
+)
+
+type updateCall struct {
+	done   chan struct{}
+	result *UpdateResult
+	err    error
+}
+
+func (c *updateCall) wait(ctx context.Context) (*UpdateResult, error) {
+	select {
+	case <-c.done:
+		return c.result, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
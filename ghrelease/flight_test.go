@@ -0,0 +1,170 @@
+package ghrelease
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func gatedHandler(next http.Handler, prefix string, entered chan<- struct{}, release <-chan struct{}) http.Handler {
+	var once sync.Once
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			once.Do(func() { close(entered) })
+			<-release
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestUpdater_UpdateWithResult_singleFlight(t *testing.T) {
+	tmpDir := t.TempDir()
+	latest := "v1.0.0"
+	server := newFakeGitHub(t, &latest, map[string]fakeRelease{
+		"v1.0.0": {tag: "v1.0.0", files: map[string]string{"a.md": "a", "b.md": "b"}},
+	})
+
+	var downloads atomic.Int32
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	server.Config.Handler = gatedHandler(countingHandler(server.Config.Handler, "/zipball/", &downloads), "/zipball/", entered, release)
+
+	updater := mustNewUpdater(t, UpdaterConfig{
+		MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+		Targets:      []ExtractTarget{{PathTransformer: &KeepAllTransformer{}, DestDir: filepath.Join(tmpDir, "dest")}},
+	})
+	useFakeGitHub(t, updater, server)
+
+	const callers = 8
+	results := make([]*UpdateResult, callers)
+	errs := make([]error, callers)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[0], errs[0] = updater.UpdateWithResult(context.Background())
+	}()
+	<-entered
+
+	for i := 1; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = updater.UpdateWithResult(context.Background())
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for i := range results {
+		if errs[i] != nil {
+			t.Fatalf("caller %d: UpdateWithResult() error = %v", i, errs[i])
+		}
+		if results[i] != results[0] {
+			t.Errorf("caller %d received a different result", i)
+		}
+	}
+	if got := downloads.Load(); got != 1 {
+		t.Errorf("downloads = %d, want 1", got)
+	}
+	if got := results[0].Targets[0].Written; got != 2 {
+		t.Errorf("Written = %d, want 2", got)
+	}
+}
+
+func TestUpdater_UpdateWithResult_waiterContext(t *testing.T) {
+	tmpDir := t.TempDir()
+	latest := "v1.0.0"
+	server := newFakeGitHub(t, &latest, map[string]fakeRelease{
+		"v1.0.0": {tag: "v1.0.0", files: map[string]string{"a.md": "a"}},
+	})
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	server.Config.Handler = gatedHandler(server.Config.Handler, "/zipball/", entered, release)
+
+	updater := mustNewUpdater(t, UpdaterConfig{
+		MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+		Targets:      []ExtractTarget{{PathTransformer: &KeepAllTransformer{}, DestDir: filepath.Join(tmpDir, "dest")}},
+	})
+	useFakeGitHub(t, updater, server)
+
+	done := make(chan error)
+	go func() {
+		_, err := updater.UpdateWithResult(context.Background())
+		done <- err
+	}()
+	<-entered
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := updater.UpdateWithResult(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("waiting UpdateWithResult() error = %v, want context.Canceled", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("leading UpdateWithResult() error = %v", err)
+	}
+}
+
+func TestUpdater_concurrentUse(t *testing.T) {
+	tmpDir := t.TempDir()
+	latest := "v1.0.0"
+	server := newFakeGitHub(t, &latest, map[string]fakeRelease{
+		"v1.0.0": {tag: "v1.0.0", files: map[string]string{"a.md": "a", "docs/b.md": "b"}},
+	})
+
+	updater := mustNewUpdater(t, UpdaterConfig{
+		MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+		Targets:      []ExtractTarget{{PathTransformer: &KeepAllTransformer{}, DestDir: filepath.Join(tmpDir, "dest")}},
+		CacheTTL:     time.Minute,
+	})
+	useFakeGitHub(t, updater, server)
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	errs := make(chan error, 30)
+	for i := 0; i < 10; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			if _, err := updater.UpdateWithResult(ctx); err != nil {
+				errs <- err
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := updater.Check(ctx); err != nil {
+				errs <- err
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := updater.Verify(ctx); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("concurrent call error = %v", err)
+	}
+
+	result, err := updater.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !result.OK() || result.Version != "v1.0.0" || result.Files != 2 {
+		t.Errorf("Verify() = %+v, want 2 intact files at v1.0.0", result)
+	}
+}
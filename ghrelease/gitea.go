@@ -0,0 +1,156 @@
+package ghrelease
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const giteaPageLimit = 50
+
+type giteaRelease struct {
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	Body        string    `json:"body"`
+	HTMLURL     string    `json:"html_url"`
+	PublishedAt time.Time `json:"published_at"`
+	Prerelease  bool      `json:"prerelease"`
+	Draft       bool      `json:"draft"`
+	Assets      []struct {
+		ID                 int64  `json:"id"`
+		Name               string `json:"name"`
+		Size               int64  `json:"size"`
+		BrowserDownloadURL string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+func NewGiteaSource(baseURL, owner, repo string) *GiteaSource {
+	return &GiteaSource{BaseURL: baseURL, Owner: owner, Repo: repo}
+}
+
+func (s *GiteaSource) ListReleases(ctx context.Context) ([]*Release, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+
+	seen := 0
+	pageURL := func(page int) string {
+		return s.repoURL("/releases") + "?" + url.Values{
+			"page":  {strconv.Itoa(page)},
+			"limit": {strconv.Itoa(giteaPageLimit)},
+		}.Encode()
+	}
+	list, err := listPages[giteaRelease](ctx, s.httpClient(), s.header(), pageURL, func(h http.Header, page, n int) int {
+		seen += n
+		total, err := strconv.Atoi(h.Get("X-Total-Count"))
+		if n == 0 || (err == nil && seen >= total) {
+			return 0
+		}
+		return page + 1
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list releases: %w", err)
+	}
+
+	var releases []*Release
+	for _, r := range list {
+		if !r.Draft {
+			releases = append(releases, s.newRelease(r))
+		}
+	}
+	return releases, nil
+}
+
+func (s *GiteaSource) LatestRelease(ctx context.Context) (*Release, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+
+	var r giteaRelease
+	if _, err := getJSON(ctx, s.httpClient(), s.repoURL("/releases/latest"), s.header(), &r); err != nil {
+		return nil, fmt.Errorf("failed to get latest release: %w", err)
+	}
+	return s.newRelease(r), nil
+}
+
+func (s *GiteaSource) ReleaseByTag(ctx context.Context, tag string) (*Release, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+
+	var r giteaRelease
+	if _, err := getJSON(ctx, s.httpClient(), s.repoURL("/releases/tags/"+url.PathEscape(tag)), s.header(), &r); err != nil {
+		return nil, fmt.Errorf("failed to get release info: %w", err)
+	}
+	return s.newRelease(r), nil
+}
+
+func (s *GiteaSource) OpenArchive(ctx context.Context, release *Release) (io.ReadCloser, error) {
+	if release.ArchiveURL == "" {
+		return nil, fmt.Errorf("release %s has no archive url", release.Tag)
+	}
+	return openURL(ctx, s.httpClient(), release.ArchiveURL, s.headerFor(release.ArchiveURL))
+}
+
+func (s *GiteaSource) OpenAsset(ctx context.Context, asset Asset) (io.ReadCloser, error) {
+	if asset.DownloadURL == "" {
+		return nil, fmt.Errorf("asset %s has no download url", asset.Name)
+	}
+	return openURL(ctx, s.httpClient(), asset.DownloadURL, s.headerFor(asset.DownloadURL))
+}
+
+func (s *GiteaSource) newRelease(r giteaRelease) *Release {
+	release := &Release{
+		Tag:         r.TagName,
+		Name:        r.Name,
+		Body:        r.Body,
+		HTMLURL:     r.HTMLURL,
+		PublishedAt: r.PublishedAt,
+		Prerelease:  r.Prerelease,
+		ArchiveURL:  s.repoURL("/archive/" + url.PathEscape(r.TagName) + ".zip"),
+	}
+	for _, a := range r.Assets {
+		release.Assets = append(release.Assets, Asset{
+			ID:          a.ID,
+			Name:        a.Name,
+			Size:        a.Size,
+			DownloadURL: a.BrowserDownloadURL,
+		})
+	}
+	return release
+}
+
+func (s *GiteaSource) validate() error {
+	if s.BaseURL == "" {
+		return fmt.Errorf("gitea base url cannot be empty")
+	}
+	return nil
+}
+
+func (s *GiteaSource) repoURL(path string) string {
+	return strings.TrimSuffix(s.BaseURL, "/") + "/api/v1/repos/" + url.PathEscape(s.Owner) + "/" + url.PathEscape(s.Repo) + path
+}
+
+func (s *GiteaSource) header() http.Header {
+	header := http.Header{}
+	if s.Token != "" {
+		header.Set("Authorization", "token "+s.Token)
+	}
+	return header
+}
+
+func (s *GiteaSource) headerFor(rawURL string) http.Header {
+	if !sameHost(rawURL, s.BaseURL) {
+		return nil
+	}
+	return s.header()
+}
+
+func (s *GiteaSource) httpClient() *http.Client {
+	return httpClientOrDefault(s.HTTPClient)
+}
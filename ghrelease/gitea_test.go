@@ -0,0 +1,207 @@
+package ghrelease
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func newFakeGitea(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+
+	files := map[string]map[string]string{
+		"v1.0.0": {"agents/a.md": "a1"},
+		"v1.1.0": {"agents/a.md": "a2", "agents/b.md": "b2"},
+	}
+
+	var server *httptest.Server
+	release := func(tag string, prerelease, draft bool) map[string]any {
+		return map[string]any{
+			"id":           len(tag),
+			"tag_name":     tag,
+			"name":         "Release " + tag,
+			"body":         "notes for " + tag,
+			"html_url":     server.URL + "/mirror/pack/releases/tag/" + tag,
+			"published_at": "2024-01-02T03:04:05Z",
+			"prerelease":   prerelease,
+			"draft":        draft,
+			"assets": []map[string]any{
+				{"id": 3, "name": "pack.zip", "size": 13, "browser_download_url": server.URL + "/attachments/0d2c"},
+			},
+		}
+	}
+
+	mux := http.NewServeMux()
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && r.Header.Get("Authorization") != "token "+token {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	releases := []map[string]any{
+		release("v1.2.0", false, true),
+		release("v1.2.0-rc1", true, false),
+		release("v1.1.0", false, false),
+		release("v1.0.0", false, false),
+	}
+
+	const prefix = "/gitea/api/v1/repos/mirror/pack"
+	mux.HandleFunc(prefix+"/releases", func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		const serverLimit = 2
+		start := min((page-1)*serverLimit, len(releases))
+		end := min(start+serverLimit, len(releases))
+		w.Header().Set("X-Total-Count", strconv.Itoa(len(releases)))
+		json.NewEncoder(w).Encode(releases[start:end])
+	})
+	mux.HandleFunc(prefix+"/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(releases[2])
+	})
+	mux.HandleFunc(prefix+"/releases/tags/", func(w http.ResponseWriter, r *http.Request) {
+		tag := strings.TrimPrefix(r.URL.Path, prefix+"/releases/tags/")
+		for _, release := range releases {
+			if release["tag_name"] == tag {
+				json.NewEncoder(w).Encode(release)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc(prefix+"/archive/", func(w http.ResponseWriter, r *http.Request) {
+		tag := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, prefix+"/archive/"), ".zip")
+		release, ok := files[tag]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		rooted := make(map[string]string, len(release))
+		for name, content := range release {
+			rooted["pack/"+name] = content
+		}
+		w.Write(createTestZip(t, rooted))
+	})
+	mux.HandleFunc("/attachments/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("attachment"))
+	})
+
+	return server
+}
+
+func TestGiteaSource(t *testing.T) {
+	server := newFakeGitea(t, "secret")
+	source := NewGiteaSource(server.URL+"/gitea/", "mirror", "pack")
+	source.Token = "secret"
+	ctx := context.Background()
+
+	releases, err := source.ListReleases(ctx)
+	if err != nil {
+		t.Fatalf("ListReleases() error = %v", err)
+	}
+	var tags []string
+	for _, release := range releases {
+		tags = append(tags, release.Tag)
+	}
+	if want := []string{"v1.2.0-rc1", "v1.1.0", "v1.0.0"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("ListReleases() tags = %v, want %v (drafts skipped)", tags, want)
+	}
+	if !releases[0].Prerelease {
+		t.Error("v1.2.0-rc1 should be a prerelease")
+	}
+
+	latest, err := source.LatestRelease(ctx)
+	if err != nil {
+		t.Fatalf("LatestRelease() error = %v", err)
+	}
+	if latest.Tag != "v1.1.0" || latest.Body != "notes for v1.1.0" || len(latest.Assets) != 1 {
+		t.Errorf("LatestRelease() = %+v", latest)
+	}
+
+	release, err := source.ReleaseByTag(ctx, "v1.0.0")
+	if err != nil {
+		t.Fatalf("ReleaseByTag() error = %v", err)
+	}
+	if release.Tag != "v1.0.0" || !strings.HasSuffix(release.ArchiveURL, "/gitea/api/v1/repos/mirror/pack/archive/v1.0.0.zip") {
+		t.Errorf("ReleaseByTag() = %+v", release)
+	}
+
+	rc, err := source.OpenAsset(ctx, latest.Assets[0])
+	if err != nil {
+		t.Fatalf("OpenAsset() error = %v", err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(data) != "attachment" {
+		t.Errorf("OpenAsset() = %q, %v", data, err)
+	}
+}
+
+func TestGiteaSource_errors(t *testing.T) {
+	server := newFakeGitea(t, "secret")
+
+	tests := []struct {
+		name   string
+		source *GiteaSource
+	}{
+		{name: "missing token", source: NewGiteaSource(server.URL+"/gitea", "mirror", "pack")},
+		{name: "wrong token", source: &GiteaSource{BaseURL: server.URL + "/gitea", Owner: "mirror", Repo: "pack", Token: "nope"}},
+		{name: "empty base url", source: &GiteaSource{Owner: "mirror", Repo: "pack", Token: "secret"}},
+		{name: "unknown repo", source: &GiteaSource{BaseURL: server.URL + "/gitea", Owner: "mirror", Repo: "other", Token: "secret"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.source.LatestRelease(context.Background()); err == nil {
+				t.Error("LatestRelease() should fail")
+			}
+		})
+	}
+}
+
+func TestUpdater_switchSource(t *testing.T) {
+	latest := "v1.1.0"
+	github := newFakeGitHub(t, &latest, map[string]fakeRelease{
+		"v1.1.0": {tag: "v1.1.0", files: map[string]string{"agents/a.md": "a2", "agents/b.md": "b2"}},
+	})
+	gitea := newFakeGitea(t, "")
+
+	githubSource := newTestGitHubSource(t, github)
+	giteaSource := NewGiteaSource(gitea.URL+"/gitea", "mirror", "pack")
+
+	for name, source := range map[string]Source{"github": githubSource, "gitea": giteaSource} {
+		t.Run(name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			agentsDir := filepath.Join(tmpDir, "agents")
+			updater, err := NewUpdater(UpdaterConfig{
+				Source:       source,
+				MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+				Targets:      []ExtractTarget{{PathTransformer: &SubDirTransformer{SubDir: "agents"}, DestDir: agentsDir}},
+			})
+			if err != nil {
+				t.Fatalf("NewUpdater() error = %v", err)
+			}
+
+			result, err := updater.UpdateWithResult(context.Background())
+			if err != nil {
+				t.Fatalf("UpdateWithResult() error = %v", err)
+			}
+			if result.Version != "v1.1.0" || result.Targets[0].Written != 2 {
+				t.Errorf("UpdateWithResult() = %+v", result)
+			}
+			content, err := os.ReadFile(filepath.Join(agentsDir, "b.md"))
+			if err != nil || string(content) != "b2" {
+				t.Errorf("b.md = %q, %v", content, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package ghrelease
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/go-github/v68/github"
+)
+
+func NewGitHubSource(owner, repo string) *GitHubSource {
+	return &GitHubSource{Owner: owner, Repo: repo, Client: github.NewClient(nil)}
+}
+
+func (s *GitHubSource) LatestRelease(ctx context.Context) (*Release, error) {
+	switch {
+	case s.Branch != "":
+		return s.commitRelease(ctx, s.Branch, "refs/heads/"+s.Branch)
+	case s.TagPattern != "":
+		return s.latestTagRelease(ctx)
+	}
+
+	r, _, err := s.client().Repositories.GetLatestRelease(ctx, s.Owner, s.Repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest release: %w", err)
+	}
+	return newRelease(r)
+}
+
+func (s *GitHubSource) ReleaseByTag(ctx context.Context, tag string) (*Release, error) {
+	if s.tracksRef() {
+		return s.commitRelease(ctx, tag, tag)
+	}
+
+	r, _, err := s.client().Repositories.GetReleaseByTag(ctx, s.Owner, s.Repo, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release info: %w", err)
+	}
+	return newRelease(r)
+}
+
+func (s *GitHubSource) ListReleases(ctx context.Context) ([]*Release, error) {
+	switch {
+	case s.Branch != "":
+		return s.listCommitReleases(ctx)
+	case s.TagPattern != "":
+		return s.listTagReleases(ctx)
+	}
+
+	var releases []*Release
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := s.client().Repositories.ListReleases(ctx, s.Owner, s.Repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list releases: %w", err)
+		}
+		for _, r := range page {
+			release, err := newRelease(r)
+			if err != nil {
+				return nil, err
+			}
+			releases = append(releases, release)
+		}
+		if resp.NextPage == 0 {
+			return releases, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func (s *GitHubSource) OpenArchive(ctx context.Context, release *Release) (io.ReadCloser, error) {
+	if release.ArchiveURL == "" {
+		return nil, fmt.Errorf("release zipball_url is empty")
+	}
+	return openURL(ctx, s.httpClient(), release.ArchiveURL, nil)
+}
+
+func (s *GitHubSource) OpenAsset(ctx context.Context, asset Asset) (io.ReadCloser, error) {
+	if asset.DownloadURL == "" {
+		return nil, fmt.Errorf("asset %s has no download url", asset.Name)
+	}
+	return openURL(ctx, s.httpClient(), asset.DownloadURL, nil)
+}
+
+func (s *GitHubSource) client() *github.Client {
+	if s.Client == nil {
+		return github.NewClient(nil)
+	}
+	return s.Client
+}
+
+func (s *GitHubSource) httpClient() *http.Client {
+	if s.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return s.HTTPClient
+}
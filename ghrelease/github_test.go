@@ -0,0 +1,157 @@
+package ghrelease
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v68/github"
+)
+
+func newTestGitHubSource(t *testing.T, server *httptest.Server) *GitHubSource {
+	t.Helper()
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatalf("failed to parse server url: %v", err)
+	}
+	client := github.NewClient(nil)
+	client.BaseURL = baseURL
+	return &GitHubSource{Owner: "owner", Repo: "repo", Client: client}
+}
+
+func TestGitHubSource_releases(t *testing.T) {
+	latest := "v1.1.0"
+	server := newFakeGitHub(t, &latest, map[string]fakeRelease{
+		"v1.0.0": {tag: "v1.0.0"},
+		"v1.1.0": {tag: "v1.1.0"},
+	})
+	source := newTestGitHubSource(t, server)
+
+	release, err := source.LatestRelease(context.Background())
+	if err != nil {
+		t.Fatalf("LatestRelease() error = %v", err)
+	}
+	if release.Tag != "v1.1.0" || len(release.Assets) != 1 || release.Assets[0].Name != "pack.zip" {
+		t.Errorf("LatestRelease() = %+v", release)
+	}
+
+	release, err = source.ReleaseByTag(context.Background(), "v1.0.0")
+	if err != nil {
+		t.Fatalf("ReleaseByTag() error = %v", err)
+	}
+	if release.Tag != "v1.0.0" {
+		t.Errorf("ReleaseByTag() tag = %q, want v1.0.0", release.Tag)
+	}
+
+	if _, err := source.ReleaseByTag(context.Background(), "v9.9.9"); err == nil {
+		t.Error("ReleaseByTag() should fail for unknown tag")
+	}
+}
+
+func TestGitHubSource_ListReleases(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/repo/releases" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`[{"tag_name": "v1.0.0"}]`))
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s/repos/owner/repo/releases?page=2>; rel="next"`, server.URL))
+		w.Write([]byte(`[{"tag_name": "v1.2.0"}, {"tag_name": "v1.1.0"}]`))
+	}))
+	t.Cleanup(server.Close)
+
+	releases, err := newTestGitHubSource(t, server).ListReleases(context.Background())
+	if err != nil {
+		t.Fatalf("ListReleases() error = %v", err)
+	}
+	var tags []string
+	for _, release := range releases {
+		tags = append(tags, release.Tag)
+	}
+	if want := []string{"v1.2.0", "v1.1.0", "v1.0.0"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("ListReleases() tags = %v, want %v", tags, want)
+	}
+}
+
+func TestGitHubSource_OpenArchive(t *testing.T) {
+	latest := "v1.0.0"
+	server := newFakeGitHub(t, &latest, map[string]fakeRelease{
+		"v1.0.0": {tag: "v1.0.0", files: map[string]string{"a.md": "a"}},
+	})
+	source := newTestGitHubSource(t, server)
+
+	release, err := source.LatestRelease(context.Background())
+	if err != nil {
+		t.Fatalf("LatestRelease() error = %v", err)
+	}
+	rc, err := source.OpenArchive(context.Background(), release)
+	if err != nil {
+		t.Fatalf("OpenArchive() error = %v", err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("failed to read archive: %v", err)
+	}
+	if _, err := zip.NewReader(bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Errorf("archive is not a zip: %v", err)
+	}
+
+	if _, err := source.OpenArchive(context.Background(), &Release{Tag: "v0"}); err == nil {
+		t.Error("OpenArchive() should fail without archive url")
+	}
+}
+
+func TestGitHubSource_OpenAsset(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/assets/tool.tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("asset content"))
+	}))
+	t.Cleanup(server.Close)
+	source := NewGitHubSource("owner", "repo")
+
+	tests := []struct {
+		name    string
+		asset   Asset
+		want    string
+		wantErr bool
+	}{
+		{name: "download", asset: Asset{Name: "tool.tar.gz", DownloadURL: server.URL + "/assets/tool.tar.gz"}, want: "asset content"},
+		{name: "not found", asset: Asset{Name: "missing", DownloadURL: server.URL + "/assets/missing"}, wantErr: true},
+		{name: "no url", asset: Asset{Name: "tool.tar.gz"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc, err := source.OpenAsset(context.Background(), tt.asset)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OpenAsset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer rc.Close()
+			data, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatalf("failed to read asset: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("content = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package ghrelease
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var errDeltaDisabled = errors.New("incremental updates are disabled")
+
+func (u *Updater) openDelta(ctx context.Context, release *Release) (io.ReadCloser, error) {
+	source, ok := u.source.(DeltaSource)
+	if !ok || u.needsRedownload() {
+		return nil, nil
+	}
+
+	metadata := u.loadMetadata()
+	from := metadata.Commit
+	if from == "" {
+		from = metadata.Version
+	}
+	if from == "" || from == treeRef(release) {
+		return nil, nil
+	}
+
+	rc, err := source.OpenDelta(ctx, from, release, &targetFiles{targets: u.config.Targets})
+	if errors.Is(err, errDeltaDisabled) {
+		return nil, nil
+	}
+	return rc, err
+}
+
+func (s *GitHubSource) OpenDelta(ctx context.Context, from string, to *Release, installed InstalledFiles) (io.ReadCloser, error) {
+	if !s.Incremental {
+		return nil, errDeltaDisabled
+	}
+
+	oldTree, err := s.blobTree(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	newTree, err := s.blobTree(ctx, treeRef(to))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	paths := make(map[string][]string)
+	for path, sha := range newTree {
+		if !installed.Wants(path) {
+			continue
+		}
+		if oldTree[path] == sha {
+			if content, ok := installed.Read(path); ok && gitBlobSHA(content) == sha {
+				files[path] = content
+				continue
+			}
+		}
+		paths[sha] = append(paths[sha], path)
+	}
+	return s.packBlobs(ctx, to, files, paths)
+}
@@ -0,0 +1,163 @@
+package ghrelease
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestDeltaRepo() *fakeRefRepo {
+	return &fakeRefRepo{
+		commits: map[string]fakeCommit{
+			"111111111111": {sha: "111111111111", date: "2024-01-01T00:00:00Z", files: map[string]string{
+				"agents/a.md": "a", "agents/b.md": "b", "agents/c.md": "c", "docs/guide.md": "g",
+			}},
+			"222222222222": {sha: "222222222222", date: "2024-02-01T00:00:00Z", files: map[string]string{
+				"agents/a.md": "a", "agents/b.md": "b2", "agents/d.md": "d", "docs/guide.md": "g2",
+			}},
+			"333333333333": {sha: "333333333333", date: "2024-03-01T00:00:00Z", files: map[string]string{
+				"agents/a.md": "a", "agents/b.md": "b2", "agents/d.md": "d3",
+			}},
+		},
+		branches: map[string]string{"main": "111111111111"},
+	}
+}
+
+func TestGitHubSource_incremental(t *testing.T) {
+	repo := newTestDeltaRepo()
+	source := newTestGitHubSource(t, newFakeRefGitHub(t, repo))
+	source.Branch = "main"
+	source.Incremental = true
+	updater, destDir := newTreeFetchUpdater(t, source, &SubDirTransformer{SubDir: "agents"})
+	ctx := context.Background()
+
+	if _, err := updater.UpdateWithResult(ctx); err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	assertFiles(t, destDir, map[string]string{"a.md": "a", "b.md": "b", "c.md": "c"})
+	if repo.zipballs.Load() != 1 || repo.blobs.Load() != 0 {
+		t.Fatalf("first install should use the zipball, zipballs = %d, blobs = %d", repo.zipballs.Load(), repo.blobs.Load())
+	}
+
+	repo.setBranch("main", "222222222222")
+	result, err := updater.UpdateWithResult(ctx)
+	if err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	assertFiles(t, destDir, map[string]string{"a.md": "a", "b.md": "b2", "d.md": "d"})
+	if repo.zipballs.Load() != 1 || repo.blobs.Load() != 2 {
+		t.Errorf("only changed blobs should be fetched, zipballs = %d, blobs = %d", repo.zipballs.Load(), repo.blobs.Load())
+	}
+	if result.Targets[0].Written != 2 || result.Targets[0].Removed != 1 {
+		t.Errorf("UpdateWithResult() targets = %+v", result.Targets)
+	}
+
+	if err := os.WriteFile(filepath.Join(destDir, "a.md"), []byte("local"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	repo.setBranch("main", "333333333333")
+	if _, err := updater.UpdateWithResult(ctx); err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	assertFiles(t, destDir, map[string]string{"a.md": "a", "b.md": "b2", "d.md": "d3"})
+	if repo.zipballs.Load() != 1 || repo.blobs.Load() != 4 {
+		t.Errorf("locally modified files should be fetched again, zipballs = %d, blobs = %d", repo.zipballs.Load(), repo.blobs.Load())
+	}
+}
+
+func TestGitHubSource_incrementalFallback(t *testing.T) {
+	tests := []struct {
+		name        string
+		setup       func(repo *fakeRefRepo, updater *Updater)
+		wantWarning bool
+	}{
+		{
+			name: "truncated tree",
+			setup: func(repo *fakeRefRepo, updater *Updater) {
+				repo.truncated = true
+			},
+			wantWarning: true,
+		},
+		{
+			name: "corrupt blob",
+			setup: func(repo *fakeRefRepo, updater *Updater) {
+				repo.corrupt = true
+			},
+			wantWarning: true,
+		},
+		{
+			name: "unknown previous commit",
+			setup: func(repo *fakeRefRepo, updater *Updater) {
+				os.WriteFile(updater.config.MetadataFile, []byte(`{"version": "999999999999", "commit": "999999999999"}`), 0644)
+			},
+			wantWarning: true,
+		},
+		{
+			name: "hooks configured",
+			setup: func(repo *fakeRefRepo, updater *Updater) {
+				updater.config.PreInstall = []PreInstallHook{&funcHook{pre: func(ctx context.Context, event HookEvent) error {
+					_, err := os.Stat(filepath.Join(event.ReleaseDir, "docs", "guide.md"))
+					return err
+				}}}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestDeltaRepo()
+			source := newTestGitHubSource(t, newFakeRefGitHub(t, repo))
+			source.Branch = "main"
+			source.Incremental = true
+			updater, destDir := newTreeFetchUpdater(t, source, &SubDirTransformer{SubDir: "agents"})
+			ctx := context.Background()
+
+			if _, err := updater.UpdateWithResult(ctx); err != nil {
+				t.Fatalf("UpdateWithResult() error = %v", err)
+			}
+
+			repo.setBranch("main", "222222222222")
+			tt.setup(repo, updater)
+			result, err := updater.UpdateWithResult(ctx)
+			if err != nil {
+				t.Fatalf("UpdateWithResult() error = %v", err)
+			}
+			assertFiles(t, destDir, map[string]string{"a.md": "a", "b.md": "b2", "d.md": "d"})
+			if repo.zipballs.Load() != 2 {
+				t.Errorf("update should fall back to the zipball, zipballs = %d", repo.zipballs.Load())
+			}
+			if got := len(result.Warnings) == 1 && strings.Contains(result.Warnings[0], "incremental update failed"); got != tt.wantWarning {
+				t.Errorf("Warnings = %v, want incremental warning %v", result.Warnings, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestUpdater_Plan_incrementalWarning(t *testing.T) {
+	repo := newTestDeltaRepo()
+	source := newTestGitHubSource(t, newFakeRefGitHub(t, repo))
+	source.Branch = "main"
+	source.Incremental = true
+	updater, _ := newTreeFetchUpdater(t, source, &SubDirTransformer{SubDir: "agents"})
+	ctx := context.Background()
+
+	if _, err := updater.UpdateWithResult(ctx); err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+
+	repo.setBranch("main", "222222222222")
+	repo.corrupt = true
+	plan, err := updater.Plan(ctx)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	result, err := updater.Apply(ctx, plan)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "incremental update failed") {
+		t.Errorf("Warnings = %v, want incremental warning", result.Warnings)
+	}
+}
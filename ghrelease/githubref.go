@@ -0,0 +1,125 @@
+package ghrelease
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+
+	"github.com/google/go-github/v68/github"
+)
+
+func (s *GitHubSource) tracksRef() bool {
+	return s.Branch != "" || s.TagPattern != ""
+}
+
+func (s *GitHubSource) commitRelease(ctx context.Context, sha, ref string) (*Release, error) {
+	c, _, err := s.client().Repositories.GetCommit(ctx, s.Owner, s.Repo, sha, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", sha, err)
+	}
+	return s.newCommitRelease(c, ref), nil
+}
+
+func (s *GitHubSource) latestTagRelease(ctx context.Context) (*Release, error) {
+	tags, err := s.matchingTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	releases := make([]*Release, len(tags))
+	for i, tag := range tags {
+		releases[i] = &Release{Tag: tag.GetName(), Prerelease: isPrerelease(tag.GetName())}
+	}
+	latest, err := latestStable(releases, fmt.Sprintf("tags of %s/%s matching %q", s.Owner, s.Repo, s.TagPattern))
+	if err != nil {
+		return nil, err
+	}
+	return s.commitRelease(ctx, latest.Tag, "refs/tags/"+latest.Tag)
+}
+
+func (s *GitHubSource) listCommitReleases(ctx context.Context) ([]*Release, error) {
+	opts := &github.CommitsListOptions{SHA: s.Branch, ListOptions: github.ListOptions{PerPage: 100}}
+	commits, _, err := s.client().Repositories.ListCommits(ctx, s.Owner, s.Repo, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+
+	releases := make([]*Release, len(commits))
+	for i, c := range commits {
+		releases[i] = s.newCommitRelease(c, "refs/heads/"+s.Branch)
+	}
+	return releases, nil
+}
+
+func (s *GitHubSource) listTagReleases(ctx context.Context) ([]*Release, error) {
+	tags, err := s.matchingTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	releases := make([]*Release, len(tags))
+	for i, tag := range tags {
+		sha := tag.GetCommit().GetSHA()
+		releases[i] = &Release{
+			Tag:        sha,
+			Name:       tag.GetName(),
+			Prerelease: isPrerelease(tag.GetName()),
+			ArchiveURL: s.zipballURL(sha),
+			Commit:     sha,
+			Ref:        "refs/tags/" + tag.GetName(),
+		}
+	}
+	return releases, nil
+}
+
+func (s *GitHubSource) matchingTags(ctx context.Context) ([]*github.RepositoryTag, error) {
+	if _, err := path.Match(s.TagPattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid tag pattern %q: %w", s.TagPattern, err)
+	}
+
+	var tags []*github.RepositoryTag
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := s.client().Repositories.ListTags(ctx, s.Owner, s.Repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tags: %w", err)
+		}
+		for _, tag := range page {
+			if ok, _ := path.Match(s.TagPattern, tag.GetName()); ok {
+				tags = append(tags, tag)
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		return CompareVersions(tags[i].GetName(), tags[j].GetName()) > 0
+	})
+	return tags, nil
+}
+
+func (s *GitHubSource) newCommitRelease(c *github.RepositoryCommit, ref string) *Release {
+	sha := c.GetSHA()
+	release := &Release{
+		Tag:        sha,
+		Name:       ref,
+		Body:       c.GetCommit().GetMessage(),
+		HTMLURL:    c.GetHTMLURL(),
+		ArchiveURL: s.zipballURL(sha),
+		Commit:     sha,
+		Ref:        ref,
+	}
+	if date := c.GetCommit().GetCommitter().GetDate(); !date.IsZero() {
+		release.PublishedAt = date.Time
+	}
+	return release
+}
+
+func (s *GitHubSource) zipballURL(sha string) string {
+	return s.client().BaseURL.String() + "repos/" + url.PathEscape(s.Owner) + "/" + url.PathEscape(s.Repo) + "/zipball/" + url.PathEscape(sha)
+}
@@ -0,0 +1,336 @@
+package ghrelease
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type fakeCommit struct {
+	sha   string
+	date  string
+	files map[string]string
+}
+
+type fakeRefRepo struct {
+	mu        sync.Mutex
+	commits   map[string]fakeCommit
+	branches  map[string]string
+	tags      []string
+	tagSHAs   map[string]string
+	truncated bool
+	corrupt   bool
+	zipballs  atomic.Int32
+	blobs     atomic.Int32
+}
+
+func (r *fakeRefRepo) setBranch(branch, sha string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.branches[branch] = sha
+}
+
+func (r *fakeRefRepo) resolve(ref string) (fakeCommit, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if sha, ok := r.branches[ref]; ok {
+		ref = sha
+	}
+	if sha, ok := r.tagSHAs[ref]; ok {
+		ref = sha
+	}
+	c, ok := r.commits[ref]
+	return c, ok
+}
+
+func newFakeRefGitHub(t *testing.T, repo *fakeRefRepo) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	commitJSON := func(c fakeCommit) map[string]any {
+		return map[string]any{
+			"sha":      c.sha,
+			"html_url": "https://github.com/owner/repo/commit/" + c.sha,
+			"commit": map[string]any{
+				"message":   "commit " + c.sha,
+				"committer": map[string]any{"name": "dev", "date": c.date},
+			},
+		}
+	}
+
+	mux.HandleFunc("/repos/owner/repo/commits/", func(w http.ResponseWriter, r *http.Request) {
+		c, ok := repo.resolve(strings.TrimPrefix(r.URL.Path, "/repos/owner/repo/commits/"))
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(commitJSON(c))
+	})
+	mux.HandleFunc("/repos/owner/repo/commits", func(w http.ResponseWriter, r *http.Request) {
+		tip, ok := repo.resolve(r.URL.Query().Get("sha"))
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		repo.mu.Lock()
+		var history []fakeCommit
+		for _, c := range repo.commits {
+			if c.date <= tip.date {
+				history = append(history, c)
+			}
+		}
+		repo.mu.Unlock()
+		sort.Slice(history, func(i, j int) bool { return history[i].date > history[j].date })
+
+		page := 1
+		fmt.Sscan(r.URL.Query().Get("page"), &page)
+		const pageSize = 2
+		start := min((page-1)*pageSize, len(history))
+		end := min(start+pageSize, len(history))
+		if end < len(history) {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/owner/repo/commits?sha=%s&page=%d>; rel="next"`, server.URL, tip.sha, page+1))
+		}
+
+		commits := make([]map[string]any, 0, end-start)
+		for _, c := range history[start:end] {
+			commits = append(commits, commitJSON(c))
+		}
+		json.NewEncoder(w).Encode(commits)
+	})
+	mux.HandleFunc("/repos/owner/repo/tags", func(w http.ResponseWriter, r *http.Request) {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+
+		page := 1
+		fmt.Sscan(r.URL.Query().Get("page"), &page)
+		const pageSize = 2
+		start := min((page-1)*pageSize, len(repo.tags))
+		end := min(start+pageSize, len(repo.tags))
+		if end < len(repo.tags) {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/owner/repo/tags?page=%d>; rel="next"`, server.URL, page+1))
+		}
+
+		var tags []map[string]any
+		for _, name := range repo.tags[start:end] {
+			tags = append(tags, map[string]any{"name": name, "commit": map[string]any{"sha": repo.tagSHAs[name]}})
+		}
+		json.NewEncoder(w).Encode(tags)
+	})
+	mux.HandleFunc("/repos/owner/repo/git/trees/", func(w http.ResponseWriter, r *http.Request) {
+		c, ok := repo.resolve(strings.TrimPrefix(r.URL.Path, "/repos/owner/repo/git/trees/"))
+		if !ok || r.URL.Query().Get("recursive") == "" {
+			http.NotFound(w, r)
+			return
+		}
+		dirs := make(map[string]bool)
+		var entries []map[string]any
+		for name, content := range c.files {
+			if dir, _, ok := strings.Cut(name, "/"); ok && !dirs[dir] {
+				dirs[dir] = true
+				entries = append(entries, map[string]any{"path": dir, "mode": "040000", "type": "tree", "sha": "0000"})
+			}
+			entries = append(entries, map[string]any{"path": name, "mode": "100644", "type": "blob", "sha": gitBlobSHA([]byte(content))})
+		}
+		repo.mu.Lock()
+		truncated := repo.truncated
+		repo.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]any{"sha": c.sha, "tree": entries, "truncated": truncated})
+	})
+	mux.HandleFunc("/repos/owner/repo/git/blobs/", func(w http.ResponseWriter, r *http.Request) {
+		sha := strings.TrimPrefix(r.URL.Path, "/repos/owner/repo/git/blobs/")
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		for _, c := range repo.commits {
+			for _, content := range c.files {
+				if gitBlobSHA([]byte(content)) == sha {
+					repo.blobs.Add(1)
+					if repo.corrupt {
+						content += "!"
+					}
+					w.Write([]byte(content))
+					return
+				}
+			}
+		}
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/repos/owner/repo/zipball/", func(w http.ResponseWriter, r *http.Request) {
+		c, ok := repo.resolve(strings.TrimPrefix(r.URL.Path, "/repos/owner/repo/zipball/"))
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		repo.zipballs.Add(1)
+		files := make(map[string]string, len(c.files))
+		for name, content := range c.files {
+			files["owner-repo-"+c.sha[:7]+"/"+name] = content
+		}
+		w.Write(createTestZip(t, files))
+	})
+
+	return server
+}
+
+func newTestRefRepo() *fakeRefRepo {
+	return &fakeRefRepo{
+		commits: map[string]fakeCommit{
+			"aaaaaaaaaaaa": {sha: "aaaaaaaaaaaa", date: "2024-01-01T00:00:00Z", files: map[string]string{"agents/a.md": "a1", "agents/old.md": "old", "docs/guide.md": "guide"}},
+			"bbbbbbbbbbbb": {sha: "bbbbbbbbbbbb", date: "2024-02-01T00:00:00Z", files: map[string]string{"agents/a.md": "a2", "agents/b.md": "a2", "docs/guide.md": "guide"}},
+			"cccccccccccc": {sha: "cccccccccccc", date: "2024-03-01T00:00:00Z", files: map[string]string{"agents/a.md": "a3"}},
+		},
+		branches: map[string]string{"main": "aaaaaaaaaaaa"},
+		tags:     []string{"v1.0.0", "v1.1.0", "v1.2.0-rc1", "other-9.0.0", "v1.10.0"},
+		tagSHAs: map[string]string{
+			"v1.0.0":      "aaaaaaaaaaaa",
+			"v1.1.0":      "bbbbbbbbbbbb",
+			"v1.2.0-rc1":  "cccccccccccc",
+			"other-9.0.0": "cccccccccccc",
+			"v1.10.0":     "bbbbbbbbbbbb",
+		},
+	}
+}
+
+func TestGitHubSource_branch(t *testing.T) {
+	repo := newTestRefRepo()
+	source := newTestGitHubSource(t, newFakeRefGitHub(t, repo))
+	source.Branch = "main"
+	ctx := context.Background()
+
+	release, err := source.LatestRelease(ctx)
+	if err != nil {
+		t.Fatalf("LatestRelease() error = %v", err)
+	}
+	if release.Tag != "aaaaaaaaaaaa" || release.Commit != "aaaaaaaaaaaa" || release.Ref != "refs/heads/main" ||
+		release.PublishedAt.Month() != 1 || !strings.HasSuffix(release.ArchiveURL, "/repos/owner/repo/zipball/aaaaaaaaaaaa") {
+		t.Errorf("LatestRelease() = %+v", release)
+	}
+
+	releases, err := source.ListReleases(ctx)
+	if err != nil {
+		t.Fatalf("ListReleases() error = %v", err)
+	}
+	if len(releases) != 1 || releases[0].Tag != "aaaaaaaaaaaa" {
+		t.Errorf("ListReleases() = %+v", releases)
+	}
+
+	repo.setBranch("main", "cccccccccccc")
+	releases, err = source.ListReleases(ctx)
+	if err != nil {
+		t.Fatalf("ListReleases() error = %v", err)
+	}
+	var history []string
+	for _, release := range releases {
+		history = append(history, release.Tag)
+	}
+	if want := []string{"cccccccccccc", "bbbbbbbbbbbb"}; !reflect.DeepEqual(history, want) {
+		t.Errorf("ListReleases() should list only the most recent page, tags = %v, want %v", history, want)
+	}
+	repo.setBranch("main", "aaaaaaaaaaaa")
+
+	pinned, err := source.ReleaseByTag(ctx, "bbbbbbbbbbbb")
+	if err != nil {
+		t.Fatalf("ReleaseByTag() error = %v", err)
+	}
+	if pinned.Tag != "bbbbbbbbbbbb" || pinned.Body != "commit bbbbbbbbbbbb" {
+		t.Errorf("ReleaseByTag() = %+v", pinned)
+	}
+
+	source.Branch = "missing"
+	if _, err := source.LatestRelease(ctx); err == nil {
+		t.Error("LatestRelease() on a missing branch should fail")
+	}
+}
+
+func TestGitHubSource_tagPattern(t *testing.T) {
+	repo := newTestRefRepo()
+	source := newTestGitHubSource(t, newFakeRefGitHub(t, repo))
+	source.TagPattern = "v*"
+	ctx := context.Background()
+
+	release, err := source.LatestRelease(ctx)
+	if err != nil {
+		t.Fatalf("LatestRelease() error = %v", err)
+	}
+	if release.Tag != "bbbbbbbbbbbb" || release.Ref != "refs/tags/v1.10.0" {
+		t.Errorf("LatestRelease() = %+v", release)
+	}
+
+	releases, err := source.ListReleases(ctx)
+	if err != nil {
+		t.Fatalf("ListReleases() error = %v", err)
+	}
+	var names []string
+	for _, r := range releases {
+		names = append(names, r.Name)
+	}
+	if want := []string{"v1.10.0", "v1.2.0-rc1", "v1.1.0", "v1.0.0"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ListReleases() names = %v, want %v", names, want)
+	}
+
+	for _, pattern := range []string{"[", "nothing-*"} {
+		source.TagPattern = pattern
+		if _, err := source.LatestRelease(ctx); err == nil {
+			t.Errorf("LatestRelease() with pattern %q should fail", pattern)
+		}
+	}
+}
+
+func TestUpdater_trackBranch(t *testing.T) {
+	repo := newTestRefRepo()
+	source := newTestGitHubSource(t, newFakeRefGitHub(t, repo))
+	source.Branch = "main"
+
+	tmpDir := t.TempDir()
+	agentsDir := filepath.Join(tmpDir, "agents")
+	updater := mustNewUpdater(t, UpdaterConfig{
+		Source:       source,
+		MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+		Targets:      []ExtractTarget{{PathTransformer: &SubDirTransformer{SubDir: "agents"}, DestDir: agentsDir}},
+	})
+	ctx := context.Background()
+
+	if _, err := updater.UpdateWithResult(ctx); err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	metadata := updater.loadMetadata()
+	if metadata.Version != "aaaaaaaaaaaa" || metadata.Commit != "aaaaaaaaaaaa" || metadata.Ref != "refs/heads/main" || metadata.CommitDate != "2024-01-01T00:00:00Z" {
+		t.Errorf("metadata = %+v", metadata)
+	}
+
+	result, err := updater.UpdateWithResult(ctx)
+	if err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	if result.Downloaded || repo.zipballs.Load() != 1 {
+		t.Errorf("unchanged head should not download, result = %+v, zipballs = %d", result, repo.zipballs.Load())
+	}
+	if updater.loadMetadata().Commit != "aaaaaaaaaaaa" {
+		t.Error("commit metadata should survive a no-op update")
+	}
+
+	repo.setBranch("main", "bbbbbbbbbbbb")
+	result, err = updater.UpdateWithResult(ctx)
+	if err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	if !result.Downloaded || result.PreviousVersion != "aaaaaaaaaaaa" || result.Version != "bbbbbbbbbbbb" || result.Targets[0].Removed != 1 {
+		t.Errorf("UpdateWithResult() = %+v", result)
+	}
+	assertFiles(t, agentsDir, map[string]string{"a.md": "a2", "b.md": "a2"})
+	if metadata := updater.loadMetadata(); metadata.Commit != "bbbbbbbbbbbb" || metadata.CommitDate != "2024-02-01T00:00:00Z" {
+		t.Errorf("metadata = %+v", metadata)
+	}
+}
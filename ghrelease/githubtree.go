@@ -0,0 +1,242 @@
+package ghrelease
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultFetchConcurrency = 8
+	gitSymlinkMode          = "120000"
+)
+
+var errTreeTruncated = errors.New("tree is truncated")
+
+func (u *Updater) openArchive(ctx context.Context, release *Release) (io.ReadCloser, []string, error) {
+	if release.ArchiveSHA256 != "" || u.hasHooks() {
+		rc, err := u.source.OpenArchive(ctx, release)
+		return rc, nil, err
+	}
+
+	var warnings []string
+	rc, err := u.openDelta(ctx, release)
+	switch {
+	case err == nil && rc != nil:
+		return rc, nil, nil
+	case ctx.Err() != nil:
+		return nil, nil, ctx.Err()
+	case err != nil:
+		warnings = append(warnings, fmt.Sprintf("incremental update failed, downloading the full release: %v", err))
+	}
+
+	rc, err = u.openSubDirs(ctx, release)
+	return rc, warnings, err
+}
+
+func (u *Updater) openSubDirs(ctx context.Context, release *Release) (io.ReadCloser, error) {
+	if source, ok := u.source.(SubDirSource); ok {
+		if dirs := u.subDirs(); len(dirs) > 0 {
+			return source.OpenSubDirs(ctx, release, dirs)
+		}
+	}
+	return u.source.OpenArchive(ctx, release)
+}
+
+func (u *Updater) subDirs() []string {
+	var dirs []string
+	for _, target := range u.config.Targets {
+		transformer, ok := target.PathTransformer.(*SubDirTransformer)
+		if !ok {
+			return nil
+		}
+		dir := strings.Trim(transformer.SubDir, "/")
+		if dir == "" {
+			return nil
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+func (s *GitHubSource) OpenSubDirs(ctx context.Context, release *Release, dirs []string) (io.ReadCloser, error) {
+	if !s.TreeFetch {
+		return s.OpenArchive(ctx, release)
+	}
+
+	tree, err := s.blobTree(ctx, treeRef(release))
+	if errors.Is(err, errTreeTruncated) {
+		return s.OpenArchive(ctx, release)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string][]string)
+	for path, sha := range tree {
+		if inDirs(path, dirs) {
+			paths[sha] = append(paths[sha], path)
+		}
+	}
+	return s.packBlobs(ctx, release, nil, paths)
+}
+
+func (s *GitHubSource) blobTree(ctx context.Context, ref string) (map[string]string, error) {
+	tree, _, err := s.client().Git.GetTree(ctx, s.Owner, s.Repo, ref, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree for %s: %w", ref, err)
+	}
+	if tree.GetTruncated() {
+		return nil, fmt.Errorf("%w: %s", errTreeTruncated, ref)
+	}
+
+	blobs := make(map[string]string, len(tree.Entries))
+	for _, entry := range tree.Entries {
+		if entry.GetType() == "blob" && entry.GetMode() != gitSymlinkMode {
+			blobs[entry.GetPath()] = entry.GetSHA()
+		}
+	}
+	return blobs, nil
+}
+
+func (s *GitHubSource) packBlobs(ctx context.Context, release *Release, files map[string][]byte, paths map[string][]string) (io.ReadCloser, error) {
+	blobs, err := s.fetchBlobs(ctx, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := make(map[string][]byte, len(files)+len(paths))
+	add := func(name string, content []byte) {
+		if !release.FlatArchive {
+			name = syntheticRootDir + name
+		}
+		archive[name] = content
+	}
+	for name, content := range files {
+		add(name, content)
+	}
+	for sha, names := range paths {
+		for _, name := range names {
+			add(name, blobs[sha])
+		}
+	}
+
+	data, err := zipFiles(archive)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *GitHubSource) fetchBlobs(ctx context.Context, paths map[string][]string) (map[string][]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	blobs := make(map[string][]byte, len(paths))
+	sem := make(chan struct{}, s.fetchConcurrency())
+	for sha, names := range paths {
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(sha, name string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			content, err := s.blob(ctx, sha)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to fetch %s: %w", name, err)
+					cancel()
+				}
+				return
+			}
+			blobs[sha] = content
+		}(sha, names[0])
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return blobs, nil
+}
+
+func (s *GitHubSource) blob(ctx context.Context, sha string) ([]byte, error) {
+	if content, ok := s.cachedBlob(sha); ok {
+		return content, nil
+	}
+
+	content, _, err := s.client().Git.GetBlobRaw(ctx, s.Owner, s.Repo, sha)
+	if err != nil {
+		return nil, err
+	}
+	if got := gitBlobSHA(content); got != sha {
+		return nil, fmt.Errorf("blob checksum mismatch: got %s, want %s", got, sha)
+	}
+
+	if s.BlobCacheDir != "" {
+		if err := os.MkdirAll(s.BlobCacheDir, defaultDirPerm); err == nil {
+			writeFileAtomic(filepath.Join(s.BlobCacheDir, sha), content, defaultFilePerm)
+		}
+	}
+	return content, nil
+}
+
+func (s *GitHubSource) cachedBlob(sha string) ([]byte, bool) {
+	if s.BlobCacheDir == "" {
+		return nil, false
+	}
+	content, err := os.ReadFile(filepath.Join(s.BlobCacheDir, sha))
+	if err != nil || gitBlobSHA(content) != sha {
+		return nil, false
+	}
+	return content, true
+}
+
+func (s *GitHubSource) fetchConcurrency() int {
+	if s.FetchConcurrency <= 0 {
+		return defaultFetchConcurrency
+	}
+	return s.FetchConcurrency
+}
+
+func treeRef(release *Release) string {
+	if release.Commit != "" {
+		return release.Commit
+	}
+	return release.Tag
+}
+
+func inDirs(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func gitBlobSHA(content []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,158 @@
+package ghrelease
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTreeFetchUpdater(t *testing.T, source *GitHubSource, transformer PathTransformer) (*Updater, string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	destDir := filepath.Join(tmpDir, "agents")
+	updater := mustNewUpdater(t, UpdaterConfig{
+		Source:       source,
+		MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+		Targets:      []ExtractTarget{{PathTransformer: transformer, DestDir: destDir, Mirror: true}},
+	})
+	return updater, destDir
+}
+
+func TestGitHubSource_treeFetch(t *testing.T) {
+	repo := newTestRefRepo()
+	source := newTestGitHubSource(t, newFakeRefGitHub(t, repo))
+	source.Branch = "main"
+	source.TreeFetch = true
+	source.BlobCacheDir = filepath.Join(t.TempDir(), "blobs")
+	source.FetchConcurrency = 2
+	updater, destDir := newTreeFetchUpdater(t, source, &SubDirTransformer{SubDir: "agents"})
+	ctx := context.Background()
+
+	if _, err := updater.UpdateWithResult(ctx); err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	assertFiles(t, destDir, map[string]string{"a.md": "a1", "old.md": "old"})
+	if repo.zipballs.Load() != 0 || repo.blobs.Load() != 2 {
+		t.Errorf("zipballs = %d, blobs = %d, want 0 and 2", repo.zipballs.Load(), repo.blobs.Load())
+	}
+
+	repo.setBranch("main", "bbbbbbbbbbbb")
+	if _, err := updater.UpdateWithResult(ctx); err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	assertFiles(t, destDir, map[string]string{"a.md": "a2", "b.md": "a2"})
+	if repo.blobs.Load() != 3 {
+		t.Errorf("identical blobs should be fetched once, blobs = %d, want 3", repo.blobs.Load())
+	}
+
+	cached, _ := os.ReadDir(source.BlobCacheDir)
+	if len(cached) != 3 {
+		t.Errorf("blob cache has %d entries, want 3", len(cached))
+	}
+	other, otherDir := newTreeFetchUpdater(t, source, &SubDirTransformer{SubDir: "agents"})
+	if _, err := other.UpdateWithResult(ctx); err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	assertFiles(t, otherDir, map[string]string{"a.md": "a2", "b.md": "a2"})
+	if repo.blobs.Load() != 3 {
+		t.Errorf("cached blobs should not be fetched again, blobs = %d", repo.blobs.Load())
+	}
+}
+
+func TestGitHubSource_treeFetchFallback(t *testing.T) {
+	tests := []struct {
+		name        string
+		treeFetch   bool
+		truncated   bool
+		hooked      bool
+		transformer PathTransformer
+		want        map[string]string
+	}{
+		{
+			name:        "truncated tree",
+			treeFetch:   true,
+			truncated:   true,
+			transformer: &SubDirTransformer{SubDir: "agents"},
+			want:        map[string]string{"a.md": "a1", "old.md": "old"},
+		},
+		{
+			name:        "whole archive target",
+			treeFetch:   true,
+			transformer: &ExtTransformer{Ext: ".md"},
+			want:        map[string]string{"agents/a.md": "a1", "agents/old.md": "old", "docs/guide.md": "guide"},
+		},
+		{
+			name:        "hooks configured",
+			treeFetch:   true,
+			hooked:      true,
+			transformer: &SubDirTransformer{SubDir: "agents"},
+			want:        map[string]string{"a.md": "a1", "old.md": "old"},
+		},
+		{
+			name:        "disabled",
+			transformer: &SubDirTransformer{SubDir: "agents"},
+			want:        map[string]string{"a.md": "a1", "old.md": "old"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRefRepo()
+			repo.truncated = tt.truncated
+			source := newTestGitHubSource(t, newFakeRefGitHub(t, repo))
+			source.Branch = "main"
+			source.TreeFetch = tt.treeFetch
+			updater, destDir := newTreeFetchUpdater(t, source, tt.transformer)
+			if tt.hooked {
+				updater.config.PreInstall = []PreInstallHook{&funcHook{pre: func(ctx context.Context, event HookEvent) error {
+					_, err := os.Stat(filepath.Join(event.ReleaseDir, "docs", "guide.md"))
+					return err
+				}}}
+			}
+
+			if _, err := updater.UpdateWithResult(context.Background()); err != nil {
+				t.Fatalf("UpdateWithResult() error = %v", err)
+			}
+			assertFiles(t, destDir, tt.want)
+			if repo.zipballs.Load() != 1 || repo.blobs.Load() != 0 {
+				t.Errorf("zipballs = %d, blobs = %d, want 1 and 0", repo.zipballs.Load(), repo.blobs.Load())
+			}
+		})
+	}
+}
+
+func TestGitHubSource_treeFetchCorruptBlob(t *testing.T) {
+	repo := newTestRefRepo()
+	repo.corrupt = true
+	source := newTestGitHubSource(t, newFakeRefGitHub(t, repo))
+	source.Branch = "main"
+	source.TreeFetch = true
+	source.BlobCacheDir = filepath.Join(t.TempDir(), "blobs")
+	updater, destDir := newTreeFetchUpdater(t, source, &SubDirTransformer{SubDir: "agents"})
+
+	if _, err := updater.UpdateWithResult(context.Background()); err == nil {
+		t.Fatal("UpdateWithResult() should fail on a corrupt blob")
+	}
+	if _, err := os.Stat(destDir); !os.IsNotExist(err) {
+		t.Errorf("nothing should be installed, stat error = %v", err)
+	}
+	if cached, _ := os.ReadDir(source.BlobCacheDir); len(cached) != 0 {
+		t.Errorf("corrupt blobs should not be cached, got %d entries", len(cached))
+	}
+}
+
+func TestGitBlobSHA(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"", "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391"},
+		{"hello\n", "ce013625030ba8dba906f756967f9e9ca394464a"},
+	}
+	for _, tt := range tests {
+		if got := gitBlobSHA([]byte(tt.content)); got != tt.want {
+			t.Errorf("gitBlobSHA(%q) = %s, want %s", tt.content, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package ghrelease
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultGitLabURL = "https://gitlab.com"
+
+type gitlabRelease struct {
+	TagName         string    `json:"tag_name"`
+	Name            string    `json:"name"`
+	Description     string    `json:"description"`
+	ReleasedAt      time.Time `json:"released_at"`
+	UpcomingRelease bool      `json:"upcoming_release"`
+	Links           struct {
+		Self string `json:"self"`
+	} `json:"_links"`
+	Assets struct {
+		Links []struct {
+			ID             int64  `json:"id"`
+			Name           string `json:"name"`
+			URL            string `json:"url"`
+			DirectAssetURL string `json:"direct_asset_url"`
+		} `json:"links"`
+	} `json:"assets"`
+}
+
+type gitlabTag struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Commit  struct {
+		CreatedAt time.Time `json:"created_at"`
+	} `json:"commit"`
+	Release *struct {
+		Description string `json:"description"`
+	} `json:"release"`
+}
+
+func NewGitLabSource(baseURL, project string) *GitLabSource {
+	return &GitLabSource{BaseURL: baseURL, Project: project}
+}
+
+func (s *GitLabSource) ListReleases(ctx context.Context) ([]*Release, error) {
+	var releases []*Release
+	for page := 1; page > 0; {
+		list, next, err := s.releasePage(ctx, page)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, list...)
+		page = next
+	}
+	return releases, nil
+}
+
+func (s *GitLabSource) LatestRelease(ctx context.Context) (*Release, error) {
+	for page := 1; page > 0; {
+		list, next, err := s.releasePage(ctx, page)
+		if err != nil {
+			return nil, err
+		}
+		for _, release := range list {
+			if !release.Prerelease {
+				return release, nil
+			}
+		}
+		page = next
+	}
+	return nil, fmt.Errorf("failed to get latest release: no releases in %s", s.Project)
+}
+
+func (s *GitLabSource) ReleaseByTag(ctx context.Context, tag string) (*Release, error) {
+	if s.UseTags {
+		var t gitlabTag
+		if _, err := getJSON(ctx, s.httpClient(), s.projectURL("/repository/tags/"+url.PathEscape(tag)), s.header(), &t); err != nil {
+			return nil, fmt.Errorf("failed to get tag info: %w", err)
+		}
+		return s.newTagRelease(t), nil
+	}
+
+	var r gitlabRelease
+	if _, err := getJSON(ctx, s.httpClient(), s.projectURL("/releases/"+url.PathEscape(tag)), s.header(), &r); err != nil {
+		return nil, fmt.Errorf("failed to get release info: %w", err)
+	}
+	return s.newRelease(r), nil
+}
+
+func (s *GitLabSource) OpenArchive(ctx context.Context, release *Release) (io.ReadCloser, error) {
+	if release.ArchiveURL == "" {
+		return nil, fmt.Errorf("release %s has no archive url", release.Tag)
+	}
+	return openURL(ctx, s.httpClient(), release.ArchiveURL, s.headerFor(release.ArchiveURL))
+}
+
+func (s *GitLabSource) OpenAsset(ctx context.Context, asset Asset) (io.ReadCloser, error) {
+	if asset.DownloadURL == "" {
+		return nil, fmt.Errorf("asset %s has no download url", asset.Name)
+	}
+	return openURL(ctx, s.httpClient(), asset.DownloadURL, s.headerFor(asset.DownloadURL))
+}
+
+func (s *GitLabSource) releasePage(ctx context.Context, page int) ([]*Release, int, error) {
+	if s.UseTags {
+		tags, next, err := gitlabPage[gitlabTag](ctx, s, "/repository/tags", url.Values{"order_by": {"version"}, "sort": {"desc"}}, page)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to list tags: %w", err)
+		}
+		releases := make([]*Release, len(tags))
+		for i, tag := range tags {
+			releases[i] = s.newTagRelease(tag)
+		}
+		return releases, next, nil
+	}
+
+	list, next, err := gitlabPage[gitlabRelease](ctx, s, "/releases", url.Values{"order_by": {"released_at"}, "sort": {"desc"}}, page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list releases: %w", err)
+	}
+	releases := make([]*Release, len(list))
+	for i, r := range list {
+		releases[i] = s.newRelease(r)
+	}
+	return releases, next, nil
+}
+
+func gitlabPage[T any](ctx context.Context, s *GitLabSource, path string, query url.Values, page int) ([]T, int, error) {
+	query.Set("per_page", "100")
+	query.Set("page", strconv.Itoa(page))
+
+	var items []T
+	h, err := getJSON(ctx, s.httpClient(), s.projectURL(path)+"?"+query.Encode(), s.header(), &items)
+	if err != nil {
+		return nil, 0, err
+	}
+	next, _ := strconv.Atoi(h.Get("X-Next-Page"))
+	return items, next, nil
+}
+
+func (s *GitLabSource) newRelease(r gitlabRelease) *Release {
+	release := &Release{
+		Tag:         r.TagName,
+		Name:        r.Name,
+		Body:        r.Description,
+		HTMLURL:     r.Links.Self,
+		PublishedAt: r.ReleasedAt,
+		Prerelease:  r.UpcomingRelease,
+		ArchiveURL:  s.archiveURL(r.TagName),
+	}
+	for _, link := range r.Assets.Links {
+		downloadURL := link.DirectAssetURL
+		if downloadURL == "" {
+			downloadURL = link.URL
+		}
+		release.Assets = append(release.Assets, Asset{ID: link.ID, Name: link.Name, DownloadURL: downloadURL})
+	}
+	return release
+}
+
+func (s *GitLabSource) newTagRelease(t gitlabTag) *Release {
+	release := &Release{
+		Tag:         t.Name,
+		Name:        t.Name,
+		Body:        t.Message,
+		PublishedAt: t.Commit.CreatedAt,
+		Prerelease:  isPrerelease(t.Name),
+		ArchiveURL:  s.archiveURL(t.Name),
+	}
+	if t.Release != nil {
+		release.Body = t.Release.Description
+	}
+	return release
+}
+
+func (s *GitLabSource) archiveURL(tag string) string {
+	return s.projectURL("/repository/archive.zip") + "?sha=" + url.QueryEscape(tag)
+}
+
+func (s *GitLabSource) projectURL(path string) string {
+	return s.baseURL() + "/api/v4/projects/" + url.PathEscape(s.Project) + path
+}
+
+func (s *GitLabSource) baseURL() string {
+	if s.BaseURL == "" {
+		return defaultGitLabURL
+	}
+	return strings.TrimSuffix(s.BaseURL, "/")
+}
+
+func (s *GitLabSource) header() http.Header {
+	header := http.Header{}
+	switch {
+	case s.PrivateToken != "":
+		header.Set("PRIVATE-TOKEN", s.PrivateToken)
+	case s.JobToken != "":
+		header.Set("JOB-TOKEN", s.JobToken)
+	}
+	return header
+}
+
+func (s *GitLabSource) headerFor(rawURL string) http.Header {
+	if !sameHost(rawURL, s.baseURL()) {
+		return nil
+	}
+	return s.header()
+}
+
+func (s *GitLabSource) httpClient() *http.Client {
+	return httpClientOrDefault(s.HTTPClient)
+}
@@ -0,0 +1,278 @@
+package ghrelease
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fakeGitLab struct {
+	server   *httptest.Server
+	releases []map[string]any
+	tags     []map[string]any
+	files    map[string]map[string]string
+	external *httptest.Server
+	leaked   bool
+	pages    int
+}
+
+func newFakeGitLab(t *testing.T) *fakeGitLab {
+	t.Helper()
+	f := &fakeGitLab{files: map[string]map[string]string{
+		"v1.0.0": {"agents/a.md": "a1"},
+		"v1.1.0": {"agents/a.md": "a2", "agents/b.md": "b2"},
+	}}
+
+	f.external = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "" || r.Header.Get("JOB-TOKEN") != "" {
+			f.leaked = true
+		}
+		w.Write([]byte("external asset"))
+	}))
+	t.Cleanup(f.external.Close)
+
+	mux := http.NewServeMux()
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" && r.Header.Get("JOB-TOKEN") != "job" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(f.server.Close)
+
+	release := func(tag string, upcoming bool) map[string]any {
+		return map[string]any{
+			"tag_name":         tag,
+			"name":             "Release " + tag,
+			"description":      "notes for " + tag,
+			"released_at":      "2024-01-02T03:04:05Z",
+			"upcoming_release": upcoming,
+			"_links":           map[string]any{"self": f.server.URL + "/group/sub/pack/-/releases/" + tag},
+			"assets": map[string]any{"links": []map[string]any{
+				{"id": 7, "name": "pack.zip", "url": f.server.URL + "/uploads/pack.zip", "direct_asset_url": f.server.URL + "/group/sub/pack/-/releases/" + tag + "/downloads/pack.zip"},
+				{"id": 8, "name": "mirror.zip", "url": f.external.URL + "/mirror.zip"},
+			}},
+		}
+	}
+	f.releases = []map[string]any{release("v2.0.0-rc1", true), release("v1.1.0", false), release("v1.0.0", false)}
+	f.tags = []map[string]any{
+		{"name": "v2.0.0-rc1", "message": "tag v2.0.0-rc1", "commit": map[string]any{"created_at": "2024-02-01T03:04:05Z"}},
+		{"name": "v1.1.0", "message": "tag v1.1.0", "commit": map[string]any{"created_at": "2024-01-02T03:04:05Z"}},
+		{"name": "v1.0.0", "message": "tag v1.0.0", "commit": map[string]any{"created_at": "2024-01-01T03:04:05Z"}},
+	}
+
+	const prefix = "/api/v4/projects/group%2Fsub%2Fpack"
+	mux.HandleFunc("/api/v4/projects/", func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.EscapedPath()
+		if !strings.HasPrefix(path, prefix) {
+			http.NotFound(w, r)
+			return
+		}
+		path = strings.TrimPrefix(path, prefix)
+
+		switch {
+		case path == "/releases":
+			f.pages++
+			if r.URL.Query().Get("page") == "2" {
+				json.NewEncoder(w).Encode(f.releases[2:])
+				return
+			}
+			w.Header().Set("X-Next-Page", "2")
+			json.NewEncoder(w).Encode(f.releases[:2])
+		case strings.HasPrefix(path, "/releases/"):
+			for _, release := range f.releases {
+				if release["tag_name"] == strings.TrimPrefix(path, "/releases/") {
+					json.NewEncoder(w).Encode(release)
+					return
+				}
+			}
+			http.NotFound(w, r)
+		case path == "/repository/tags":
+			json.NewEncoder(w).Encode(f.tags)
+		case strings.HasPrefix(path, "/repository/tags/"):
+			for _, tag := range f.tags {
+				if tag["name"] == strings.TrimPrefix(path, "/repository/tags/") {
+					json.NewEncoder(w).Encode(tag)
+					return
+				}
+			}
+			http.NotFound(w, r)
+		case path == "/repository/archive.zip":
+			tag := r.URL.Query().Get("sha")
+			files, ok := f.files[tag]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			rooted := make(map[string]string, len(files))
+			for name, content := range files {
+				rooted["pack-"+tag+"-0123abcd/"+name] = content
+			}
+			w.Write(createTestZip(t, rooted))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	mux.HandleFunc("/group/sub/pack/-/releases/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("project asset"))
+	})
+
+	return f
+}
+
+func TestGitLabSource_releases(t *testing.T) {
+	f := newFakeGitLab(t)
+	source := &GitLabSource{BaseURL: f.server.URL + "/", Project: "group/sub/pack", PrivateToken: "secret"}
+
+	releases, err := source.ListReleases(context.Background())
+	if err != nil {
+		t.Fatalf("ListReleases() error = %v", err)
+	}
+	var tags []string
+	for _, release := range releases {
+		tags = append(tags, release.Tag)
+	}
+	if want := []string{"v2.0.0-rc1", "v1.1.0", "v1.0.0"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("ListReleases() tags = %v, want %v", tags, want)
+	}
+
+	f.pages = 0
+	latest, err := source.LatestRelease(context.Background())
+	if err != nil {
+		t.Fatalf("LatestRelease() error = %v", err)
+	}
+	if latest.Tag != "v1.1.0" || latest.Body != "notes for v1.1.0" || latest.PublishedAt.IsZero() {
+		t.Errorf("LatestRelease() = %+v, want v1.1.0 skipping upcoming release", latest)
+	}
+	if f.pages != 1 {
+		t.Errorf("LatestRelease() fetched %d pages, want 1", f.pages)
+	}
+	if len(latest.Assets) != 2 || !strings.HasSuffix(latest.Assets[0].DownloadURL, "/downloads/pack.zip") {
+		t.Errorf("assets = %+v, want direct asset url", latest.Assets)
+	}
+
+	release, err := source.ReleaseByTag(context.Background(), "v1.0.0")
+	if err != nil {
+		t.Fatalf("ReleaseByTag() error = %v", err)
+	}
+	if release.Tag != "v1.0.0" || !strings.Contains(release.ArchiveURL, "sha=v1.0.0") {
+		t.Errorf("ReleaseByTag() = %+v", release)
+	}
+
+	if _, err := source.ReleaseByTag(context.Background(), "v9.9.9"); err == nil {
+		t.Error("ReleaseByTag() should fail for unknown tag")
+	}
+}
+
+func TestGitLabSource_tags(t *testing.T) {
+	f := newFakeGitLab(t)
+	source := &GitLabSource{BaseURL: f.server.URL, Project: "group/sub/pack", JobToken: "job", UseTags: true}
+
+	latest, err := source.LatestRelease(context.Background())
+	if err != nil {
+		t.Fatalf("LatestRelease() error = %v", err)
+	}
+	if latest.Tag != "v1.1.0" || latest.Body != "tag v1.1.0" {
+		t.Errorf("LatestRelease() = %+v, want v1.1.0 skipping the rc tag", latest)
+	}
+
+	rc, err := source.ReleaseByTag(context.Background(), "v2.0.0-rc1")
+	if err != nil {
+		t.Fatalf("ReleaseByTag() error = %v", err)
+	}
+	if !rc.Prerelease {
+		t.Errorf("ReleaseByTag() = %+v, want prerelease", rc)
+	}
+
+	release, err := source.ReleaseByTag(context.Background(), "v1.0.0")
+	if err != nil {
+		t.Fatalf("ReleaseByTag() error = %v", err)
+	}
+	if release.Tag != "v1.0.0" {
+		t.Errorf("ReleaseByTag() tag = %q", release.Tag)
+	}
+}
+
+func TestGitLabSource_auth(t *testing.T) {
+	f := newFakeGitLab(t)
+
+	tests := []struct {
+		name    string
+		source  *GitLabSource
+		wantErr bool
+	}{
+		{name: "private token", source: &GitLabSource{BaseURL: f.server.URL, Project: "group/sub/pack", PrivateToken: "secret"}},
+		{name: "job token", source: &GitLabSource{BaseURL: f.server.URL, Project: "group/sub/pack", JobToken: "job"}},
+		{name: "no token", source: &GitLabSource{BaseURL: f.server.URL, Project: "group/sub/pack"}, wantErr: true},
+		{name: "wrong token", source: &GitLabSource{BaseURL: f.server.URL, Project: "group/sub/pack", PrivateToken: "nope"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.source.LatestRelease(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LatestRelease() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGitLabSource_OpenAsset(t *testing.T) {
+	f := newFakeGitLab(t)
+	source := &GitLabSource{BaseURL: f.server.URL, Project: "group/sub/pack", PrivateToken: "secret"}
+
+	release, err := source.LatestRelease(context.Background())
+	if err != nil {
+		t.Fatalf("LatestRelease() error = %v", err)
+	}
+
+	for i, want := range []string{"project asset", "external asset"} {
+		rc, err := source.OpenAsset(context.Background(), release.Assets[i])
+		if err != nil {
+			t.Fatalf("OpenAsset(%s) error = %v", release.Assets[i].Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil || string(data) != want {
+			t.Errorf("OpenAsset(%s) = %q, %v, want %q", release.Assets[i].Name, data, err, want)
+		}
+	}
+	if f.leaked {
+		t.Error("token was sent to an external host")
+	}
+}
+
+func TestUpdater_gitLabSource(t *testing.T) {
+	f := newFakeGitLab(t)
+	tmpDir := t.TempDir()
+	agentsDir := filepath.Join(tmpDir, "agents")
+
+	updater, err := NewUpdater(UpdaterConfig{
+		Source:       &GitLabSource{BaseURL: f.server.URL, Project: "group/sub/pack", PrivateToken: "secret"},
+		MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+		Targets:      []ExtractTarget{{PathTransformer: &SubDirTransformer{SubDir: "agents"}, DestDir: agentsDir}},
+	})
+	if err != nil {
+		t.Fatalf("NewUpdater() error = %v", err)
+	}
+
+	result, err := updater.UpdateWithResult(context.Background())
+	if err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	if result.Version != "v1.1.0" || result.Targets[0].Written != 2 {
+		t.Errorf("UpdateWithResult() = %+v", result)
+	}
+	content, err := os.ReadFile(filepath.Join(agentsDir, "b.md"))
+	if err != nil || string(content) != "b2" {
+		t.Errorf("b.md = %q, %v", content, err)
+	}
+}
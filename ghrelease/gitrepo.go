@@ -0,0 +1,97 @@
+package ghrelease
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultGitPath = "git"
+
+func NewGitRepoSource(repoDir string) *GitRepoSource {
+	return &GitRepoSource{RepoDir: repoDir}
+}
+
+func (s *GitRepoSource) ListReleases(ctx context.Context) ([]*Release, error) {
+	if s.RepoDir == "" {
+		return nil, fmt.Errorf("git repo dir cannot be empty")
+	}
+
+	out, err := s.git(ctx, "for-each-ref", "--format=%(refname:strip=2)%00%(creatordate:iso-strict)%00%(contents:subject)", "refs/tags")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	var releases []*Release
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.SplitN(line, "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		publishedAt, _ := time.Parse(time.RFC3339, fields[1])
+		releases = append(releases, &Release{
+			Tag:         fields[0],
+			Name:        fields[0],
+			Body:        fields[2],
+			PublishedAt: publishedAt,
+			Prerelease:  isPrerelease(fields[0]),
+		})
+	}
+
+	sort.SliceStable(releases, func(i, j int) bool {
+		return CompareVersions(releases[i].Tag, releases[j].Tag) > 0
+	})
+	return releases, nil
+}
+
+func (s *GitRepoSource) LatestRelease(ctx context.Context) (*Release, error) {
+	releases, err := s.ListReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return latestStable(releases, s.RepoDir)
+}
+
+func (s *GitRepoSource) ReleaseByTag(ctx context.Context, tag string) (*Release, error) {
+	releases, err := s.ListReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return findRelease(releases, tag, s.RepoDir)
+}
+
+func (s *GitRepoSource) OpenArchive(ctx context.Context, release *Release) (io.ReadCloser, error) {
+	out, err := s.git(ctx, "archive", "--format=zip", "--prefix="+syntheticRootDir, "refs/tags/"+release.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive %s: %w", release.Tag, err)
+	}
+	return io.NopCloser(bytes.NewReader(out)), nil
+}
+
+func (s *GitRepoSource) OpenAsset(ctx context.Context, asset Asset) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("git repo source has no asset %s", asset.Name)
+}
+
+func (s *GitRepoSource) git(ctx context.Context, args ...string) ([]byte, error) {
+	gitPath := s.GitPath
+	if gitPath == "" {
+		gitPath = defaultGitPath
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, gitPath, append([]string{"-C", s.RepoDir}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,166 @@
+package ghrelease
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newTestGitRepo(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	workDir := filepath.Join(t.TempDir(), "work")
+	bareDir := filepath.Join(t.TempDir(), "pack.git")
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_CONFIG_GLOBAL="+os.DevNull,
+			"GIT_CONFIG_NOSYSTEM=1",
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	commit := func(files map[string]string, message string) {
+		t.Helper()
+		for name, content := range files {
+			writeTestFiles(t, map[string]string{filepath.Join(workDir, name): content})
+		}
+		git(workDir, "add", "-A")
+		git(workDir, "commit", "-q", "-m", message)
+	}
+
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	git(workDir, "init", "-q")
+	commit(map[string]string{"agents/a.md": "a1", "agents/old.md": "old"}, "first")
+	git(workDir, "tag", "-a", "v1.0.0", "-m", "First release")
+	if err := os.Remove(filepath.Join(workDir, "agents/old.md")); err != nil {
+		t.Fatal(err)
+	}
+	commit(map[string]string{"agents/a.md": "a2", "agents/b.md": "b2"}, "second")
+	git(workDir, "tag", "-a", "v1.1.0", "-m", "Second release")
+	commit(map[string]string{"agents/a.md": "a3"}, "third")
+	git(workDir, "tag", "v1.2.0-rc1")
+	git(filepath.Dir(bareDir), "clone", "-q", "--bare", workDir, bareDir)
+
+	return bareDir
+}
+
+func TestGitRepoSource(t *testing.T) {
+	source := NewGitRepoSource(newTestGitRepo(t))
+	ctx := context.Background()
+
+	releases, err := source.ListReleases(ctx)
+	if err != nil {
+		t.Fatalf("ListReleases() error = %v", err)
+	}
+	var tags []string
+	for _, release := range releases {
+		tags = append(tags, release.Tag)
+	}
+	if want := []string{"v1.2.0-rc1", "v1.1.0", "v1.0.0"}; !reflect.DeepEqual(tags, want) {
+		t.Fatalf("ListReleases() tags = %v, want %v", tags, want)
+	}
+	if !releases[0].Prerelease || releases[1].Prerelease {
+		t.Errorf("prerelease = %v, %v", releases[0].Prerelease, releases[1].Prerelease)
+	}
+
+	latest, err := source.LatestRelease(ctx)
+	if err != nil {
+		t.Fatalf("LatestRelease() error = %v", err)
+	}
+	if latest.Tag != "v1.1.0" || latest.Body != "Second release" || latest.PublishedAt.IsZero() {
+		t.Errorf("LatestRelease() = %+v", latest)
+	}
+
+	if _, err := source.ReleaseByTag(ctx, "v9.9.9"); err == nil {
+		t.Error("ReleaseByTag() for an unknown tag should fail")
+	}
+	if _, err := source.OpenArchive(ctx, &Release{Tag: "v9.9.9"}); err == nil {
+		t.Error("OpenArchive() for an unknown tag should fail")
+	}
+	if _, err := source.OpenAsset(ctx, Asset{Name: "tool"}); err == nil {
+		t.Error("OpenAsset() should fail")
+	}
+}
+
+func TestGitRepoSource_errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source *GitRepoSource
+	}{
+		{name: "empty repo dir", source: &GitRepoSource{}},
+		{name: "not a repo", source: NewGitRepoSource(t.TempDir())},
+		{name: "missing git", source: &GitRepoSource{RepoDir: t.TempDir(), GitPath: filepath.Join(t.TempDir(), "git")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.source.LatestRelease(context.Background()); err == nil {
+				t.Error("LatestRelease() should fail")
+			}
+		})
+	}
+}
+
+type pinnedSource struct {
+	*GitRepoSource
+	pinned string
+}
+
+func (s *pinnedSource) LatestRelease(ctx context.Context) (*Release, error) {
+	if s.pinned != "" {
+		return s.ReleaseByTag(ctx, s.pinned)
+	}
+	return s.GitRepoSource.LatestRelease(ctx)
+}
+
+func TestUpdater_gitRepoSource(t *testing.T) {
+	source := &pinnedSource{GitRepoSource: NewGitRepoSource(newTestGitRepo(t))}
+	tmpDir := t.TempDir()
+	agentsDir := filepath.Join(tmpDir, "agents")
+	updater := mustNewUpdater(t, UpdaterConfig{
+		Source:       source,
+		MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+		Targets:      []ExtractTarget{{PathTransformer: &SubDirTransformer{SubDir: "agents"}, DestDir: agentsDir}},
+	})
+	ctx := context.Background()
+
+	source.pinned = "v1.0.0"
+	if _, err := updater.UpdateWithResult(ctx); err != nil {
+		t.Fatalf("UpdateWithResult(v1.0.0) error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(agentsDir, "old.md")); err != nil {
+		t.Fatalf("old.md should be installed from v1.0.0: %v", err)
+	}
+
+	source.pinned = ""
+	result, err := updater.UpdateWithResult(ctx)
+	if err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	if result.PreviousVersion != "v1.0.0" || result.Version != "v1.1.0" || result.Targets[0].Removed != 1 {
+		t.Errorf("UpdateWithResult() = %+v", result)
+	}
+	for name, want := range map[string]string{"a.md": "a2", "b.md": "b2"} {
+		content, err := os.ReadFile(filepath.Join(agentsDir, name))
+		if err != nil || string(content) != want {
+			t.Errorf("%s = %q, %v, want %q", name, content, err, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(agentsDir, "old.md")); !os.IsNotExist(err) {
+		t.Error("old.md should be pruned")
+	}
+}
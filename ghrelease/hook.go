@@ -0,0 +1,155 @@
+package ghrelease
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultScriptTimeout = time.Minute
+	scriptWaitDelay      = time.Second
+	releaseStagingDir    = "release"
+	targetsStagingDir    = "targets"
+)
+
+func (u *Updater) hasHooks() bool {
+	return len(u.config.PreInstall) > 0 || len(u.config.PostInstall) > 0
+}
+
+func (u *Updater) stage(plan *Plan) (*HookEvent, error) {
+	if u.config.StagingDir != "" {
+		if err := os.MkdirAll(u.config.StagingDir, defaultDirPerm); err != nil {
+			return nil, err
+		}
+	}
+	dir, err := os.MkdirTemp(u.config.StagingDir, "ghrelease-staging-")
+	if err != nil {
+		return nil, err
+	}
+
+	event := &HookEvent{
+		PreviousVersion: plan.PreviousVersion,
+		Version:         plan.Version,
+		StagingDir:      dir,
+		ReleaseDir:      filepath.Join(dir, releaseStagingDir),
+		TargetDirs:      make([]string, len(u.config.Targets)),
+	}
+	if err := u.stageFiles(plan, event); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return event, nil
+}
+
+func (u *Updater) stageFiles(plan *Plan, event *HookEvent) error {
+	for i := range u.config.Targets {
+		event.TargetDirs[i] = filepath.Join(event.StagingDir, targetsStagingDir, strconv.Itoa(i))
+		if err := os.MkdirAll(event.TargetDirs[i], defaultDirPerm); err != nil {
+			return err
+		}
+	}
+
+	for _, entry := range plan.entries {
+		path := filepath.Join(event.ReleaseDir, filepath.FromSlash(entry.path))
+		if !isWithinDir(path, event.ReleaseDir) {
+			return fmt.Errorf("archive entry escapes release dir: %s", entry.path)
+		}
+		content, err := entry.read()
+		if err != nil {
+			return err
+		}
+		if err := writeFile(path, content); err != nil {
+			return err
+		}
+	}
+
+	for _, op := range plan.Operations {
+		if op.Action == PlanDelete {
+			continue
+		}
+		rel, err := filepath.Rel(op.DestDir, op.Path)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(filepath.Join(event.TargetDirs[op.Target], rel), plan.contents[op.Hash]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *Updater) runPreInstall(ctx context.Context, event HookEvent) error {
+	for _, hook := range u.config.PreInstall {
+		if err := hook.PreInstall(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *Updater) runPostInstall(ctx context.Context, event HookEvent, result *UpdateResult) {
+	for _, hook := range u.config.PostInstall {
+		if err := hook.PostInstall(ctx, event); err != nil {
+			result.addWarning("post-install hook: %v", err)
+		}
+	}
+}
+
+func (h *ScriptHook) PreInstall(ctx context.Context, event HookEvent) error {
+	return h.run(ctx, "pre-install", event)
+}
+
+func (h *ScriptHook) PostInstall(ctx context.Context, event HookEvent) error {
+	return h.run(ctx, "post-install", event)
+}
+
+func (h *ScriptHook) run(ctx context.Context, phase string, event HookEvent) error {
+	path := filepath.Join(event.ReleaseDir, filepath.FromSlash(h.Path))
+	if !isWithinDir(path, event.ReleaseDir) {
+		return fmt.Errorf("script path escapes release dir: %s", h.Path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("script %s: %w", h.Path, err)
+	}
+	if err := os.Chmod(path, 0755); err != nil {
+		return err
+	}
+
+	timeout := h.Timeout
+	if timeout == 0 {
+		timeout = defaultScriptTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, h.Args...)
+	cmd.Dir = event.StagingDir
+	cmd.WaitDelay = scriptWaitDelay
+	cmd.Env = append(os.Environ(),
+		"GHRELEASE_PHASE="+phase,
+		"GHRELEASE_VERSION="+event.Version,
+		"GHRELEASE_PREVIOUS_VERSION="+event.PreviousVersion,
+		"GHRELEASE_STAGING_DIR="+event.StagingDir,
+		"GHRELEASE_RELEASE_DIR="+event.ReleaseDir,
+		"GHRELEASE_TARGET_DIRS="+strings.Join(event.TargetDirs, string(os.PathListSeparator)),
+	)
+
+	output, err := cmd.CombinedOutput()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("script %s timed out after %v", h.Path, timeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("script %s: %w: %s", h.Path, err, msg)
+		}
+		return fmt.Errorf("script %s: %w", h.Path, err)
+	}
+	return nil
+}
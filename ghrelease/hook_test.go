@@ -0,0 +1,208 @@
+package ghrelease
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+type funcHook struct {
+	pre  func(ctx context.Context, event HookEvent) error
+	post func(ctx context.Context, event HookEvent) error
+}
+
+func (h *funcHook) PreInstall(ctx context.Context, event HookEvent) error {
+	return h.pre(ctx, event)
+}
+
+func (h *funcHook) PostInstall(ctx context.Context, event HookEvent) error {
+	return h.post(ctx, event)
+}
+
+func newHookUpdater(t *testing.T, files map[string]string, config UpdaterConfig) (*Updater, string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	latest := "v1.0.0"
+	server := newFakeGitHub(t, &latest, map[string]fakeRelease{
+		"v1.0.0": {tag: "v1.0.0", files: files},
+	})
+
+	destDir := filepath.Join(tmpDir, "agents")
+	config.RepoOwner = "owner"
+	config.RepoName = "repo"
+	config.MetadataFile = filepath.Join(tmpDir, "metadata.json")
+	config.StagingDir = filepath.Join(tmpDir, "staging")
+	config.Targets = []ExtractTarget{{PathTransformer: &SubDirTransformer{SubDir: "agents"}, DestDir: destDir}}
+
+	updater, err := NewUpdater(config)
+	if err != nil {
+		t.Fatalf("NewUpdater() error = %v", err)
+	}
+	useFakeGitHub(t, updater, server)
+	return updater, destDir
+}
+
+func assertStagingRemoved(t *testing.T, updater *Updater) {
+	t.Helper()
+	entries, err := os.ReadDir(updater.config.StagingDir)
+	if err != nil {
+		t.Fatalf("failed to read staging dir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("staging dir not cleaned up: %v", entries)
+	}
+}
+
+func TestUpdater_hooks(t *testing.T) {
+	var preEvent, postEvent HookEvent
+	var destDir string
+	var installedDuringPre bool
+	hook := &funcHook{
+		pre: func(ctx context.Context, event HookEvent) error {
+			preEvent = event
+			content, err := os.ReadFile(filepath.Join(event.TargetDirs[0], "foo.md"))
+			if err != nil || string(content) != "foo" {
+				return errors.New("staged agent missing")
+			}
+			if _, err := os.Stat(filepath.Join(event.ReleaseDir, "scripts", "lint.sh")); err != nil {
+				return errors.New("staged release missing")
+			}
+			_, err = os.Stat(filepath.Join(destDir, "foo.md"))
+			installedDuringPre = err == nil
+			return nil
+		},
+		post: func(ctx context.Context, event HookEvent) error {
+			postEvent = event
+			return errors.New("migration failed")
+		},
+	}
+
+	updater, dir := newHookUpdater(t, map[string]string{
+		"agents/foo.md":   "foo",
+		"scripts/lint.sh": "#!/bin/sh\n",
+	}, UpdaterConfig{PreInstall: []PreInstallHook{hook}, PostInstall: []PostInstallHook{hook}})
+	destDir = dir
+
+	result, err := updater.UpdateWithResult(context.Background())
+	if err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+
+	if preEvent.Version != "v1.0.0" || preEvent.PreviousVersion != "" {
+		t.Errorf("pre-install event = %+v", preEvent)
+	}
+	if installedDuringPre {
+		t.Error("files were installed before pre-install hook ran")
+	}
+	if postEvent.Version != "v1.0.0" {
+		t.Errorf("post-install event = %+v", postEvent)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "migration failed") {
+		t.Errorf("Warnings = %v, want post-install warning", result.Warnings)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "foo.md")); err != nil {
+		t.Errorf("foo.md not installed: %v", err)
+	}
+	assertStagingRemoved(t, updater)
+}
+
+func TestUpdater_hooks_veto(t *testing.T) {
+	errInvalid := errors.New("invalid agent")
+	postCalled := false
+	hook := &funcHook{
+		pre:  func(ctx context.Context, event HookEvent) error { return errInvalid },
+		post: func(ctx context.Context, event HookEvent) error { postCalled = true; return nil },
+	}
+
+	updater, destDir := newHookUpdater(t, map[string]string{"agents/foo.md": "foo"},
+		UpdaterConfig{PreInstall: []PreInstallHook{hook}, PostInstall: []PostInstallHook{hook}})
+
+	if _, err := updater.UpdateWithResult(context.Background()); !errors.Is(err, errInvalid) {
+		t.Fatalf("UpdateWithResult() error = %v, want veto", err)
+	}
+	if postCalled {
+		t.Error("post-install hook ran after veto")
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "foo.md")); !os.IsNotExist(err) {
+		t.Error("vetoed update installed files")
+	}
+	if updater.getLocalVersion() != "" {
+		t.Errorf("local version = %q, want unchanged", updater.getLocalVersion())
+	}
+	assertStagingRemoved(t, updater)
+}
+
+func TestScriptHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not supported on windows")
+	}
+
+	tests := []struct {
+		name    string
+		script  string
+		hook    ScriptHook
+		wantErr string
+	}{
+		{
+			name:   "success",
+			script: "#!/bin/sh\ntest \"$GHRELEASE_PHASE\" = pre-install || exit 1\ntest -f \"$GHRELEASE_TARGET_DIRS/foo.md\" || exit 1\ntest \"$1\" = --strict\n",
+			hook:   ScriptHook{Path: "scripts/check.sh", Args: []string{"--strict"}},
+		},
+		{
+			name:    "failure output",
+			script:  "#!/bin/sh\necho 'foo.md: missing front matter' >&2\nexit 3\n",
+			hook:    ScriptHook{Path: "scripts/check.sh"},
+			wantErr: "missing front matter",
+		},
+		{
+			name:    "timeout",
+			script:  "#!/bin/sh\nsleep 5\n",
+			hook:    ScriptHook{Path: "scripts/check.sh", Timeout: 100 * time.Millisecond},
+			wantErr: "timed out",
+		},
+		{
+			name:    "missing script",
+			script:  "#!/bin/sh\n",
+			hook:    ScriptHook{Path: "scripts/other.sh"},
+			wantErr: "scripts/other.sh",
+		},
+		{
+			name:    "path escapes release",
+			script:  "#!/bin/sh\n",
+			hook:    ScriptHook{Path: "../check.sh"},
+			wantErr: "escapes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := tt.hook
+			updater, destDir := newHookUpdater(t, map[string]string{
+				"agents/foo.md":    "foo",
+				"scripts/check.sh": tt.script,
+			}, UpdaterConfig{PreInstall: []PreInstallHook{&hook}})
+
+			_, err := updater.UpdateWithResult(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("UpdateWithResult() error = %v", err)
+				}
+				if _, err := os.Stat(filepath.Join(destDir, "foo.md")); err != nil {
+					t.Errorf("foo.md not installed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("UpdateWithResult() error = %v, want %q", err, tt.wantErr)
+			}
+			if _, err := os.Stat(filepath.Join(destDir, "foo.md")); !os.IsNotExist(err) {
+				t.Error("failed script should veto the install")
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package ghrelease
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	defaultLockTimeout = 30 * time.Second
+	lockPollInterval   = 50 * time.Millisecond
+	lockFileSuffix     = ".lock"
+)
+
+var ErrUpdateInProgress = errors.New("update in progress")
+
+type LockOwner struct {
+	PID        int       `json:"pid"`
+	Hostname   string    `json:"hostname"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+type LockError struct {
+	LockFile string
+	Owner    *LockOwner
+}
+
+func (e *LockError) Error() string {
+	if e.Owner == nil {
+		return fmt.Sprintf("%v: lock %s is held", ErrUpdateInProgress, e.LockFile)
+	}
+	return fmt.Sprintf("%v: lock %s is held by pid %d on %s since %s",
+		ErrUpdateInProgress, e.LockFile, e.Owner.PID, e.Owner.Hostname, e.Owner.AcquiredAt.Format(time.RFC3339))
+}
+
+func (e *LockError) Unwrap() error {
+	return ErrUpdateInProgress
+}
+
+type fileLock interface {
+	unlock() error
+}
+
+func (u *Updater) lockFile() string {
+	if u.config.MetadataFile == "" {
+		return ""
+	}
+	return u.config.MetadataFile + lockFileSuffix
+}
+
+func (u *Updater) acquireLock(ctx context.Context) (fileLock, error) {
+	path := u.lockFile()
+	if path == "" {
+		return noopLock{}, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), defaultDirPerm); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.config.LockTimeout)
+	defer cancel()
+
+	return lockPath(ctx, path)
+}
+
+func waitLock(ctx context.Context, path string, try func() (bool, error)) error {
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+
+	for {
+		acquired, err := try()
+		if err != nil || acquired {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				owner, _ := readLockOwner(path)
+				return &LockError{LockFile: path, Owner: owner}
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func currentLockOwner() LockOwner {
+	hostname, _ := os.Hostname()
+	return LockOwner{PID: os.Getpid(), Hostname: hostname, AcquiredAt: time.Now().UTC()}
+}
+
+func readLockOwner(path string) (*LockOwner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var owner LockOwner
+	if err := json.Unmarshal(data, &owner); err != nil {
+		return nil, err
+	}
+	return &owner, nil
+}
+
+func (o *LockOwner) isStale() bool {
+	hostname, err := os.Hostname()
+	if err != nil || o.Hostname != hostname || o.PID <= 0 {
+		return false
+	}
+	return !processAlive(o.PID)
+}
+
+type noopLock struct{}
+
+func (noopLock) unlock() error { return nil }
+
+type exclusiveLock struct {
+	path  string
+	owner LockOwner
+}
+
+func lockExclusive(ctx context.Context, path string) (fileLock, error) {
+	lock := &exclusiveLock{path: path, owner: currentLockOwner()}
+
+	err := waitLock(ctx, path, func() (bool, error) {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, defaultFilePerm)
+		if err == nil {
+			defer f.Close()
+			return true, json.NewEncoder(f).Encode(lock.owner)
+		}
+		if !os.IsExist(err) {
+			return false, err
+		}
+
+		if owner, err := readLockOwner(path); err == nil && owner.isStale() {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return false, err
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+func (l *exclusiveLock) unlock() error {
+	owner, err := readLockOwner(l.path)
+	if err != nil {
+		return err
+	}
+	if owner.PID != l.owner.PID || owner.Hostname != l.owner.Hostname {
+		return nil
+	}
+	return os.Remove(l.path)
+}
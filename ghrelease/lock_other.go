@@ -0,0 +1,17 @@
+//go:build !unix
+
+package ghrelease
+
+import (
+	"context"
+	"os"
+)
+
+func lockPath(ctx context.Context, path string) (fileLock, error) {
+	return lockExclusive(ctx, path)
+}
+
+func processAlive(pid int) bool {
+	_, err := os.FindProcess(pid)
+	return err == nil
+}
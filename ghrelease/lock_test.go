@@ -0,0 +1,209 @@
+package ghrelease
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func deadPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("cannot spawn helper process: %v", err)
+	}
+	return cmd.ProcessState.Pid()
+}
+
+func writeLockOwner(t *testing.T, path string, owner LockOwner) {
+	t.Helper()
+	data, err := json.Marshal(owner)
+	if err != nil {
+		t.Fatalf("failed to marshal owner: %v", err)
+	}
+	writeTestFiles(t, map[string]string{path: string(data)})
+}
+
+func TestUpdater_acquireLock(t *testing.T) {
+	metadataFile := filepath.Join(t.TempDir(), "state", "metadata.json")
+	config := UpdaterConfig{MetadataFile: metadataFile, LockTimeout: 100 * time.Millisecond}
+	first := mustNewUpdater(t, config)
+	second := mustNewUpdater(t, config)
+
+	lock, err := first.acquireLock(context.Background())
+	if err != nil {
+		t.Fatalf("acquireLock() error = %v", err)
+	}
+
+	_, err = second.acquireLock(context.Background())
+	if !errors.Is(err, ErrUpdateInProgress) {
+		t.Fatalf("acquireLock() error = %v, want ErrUpdateInProgress", err)
+	}
+	var lockErr *LockError
+	if !errors.As(err, &lockErr) {
+		t.Fatalf("acquireLock() error = %T, want *LockError", err)
+	}
+	if lockErr.Owner == nil || lockErr.Owner.PID != os.Getpid() {
+		t.Errorf("LockError.Owner = %+v, want current pid", lockErr.Owner)
+	}
+	if lockErr.LockFile != metadataFile+lockFileSuffix {
+		t.Errorf("LockError.LockFile = %q", lockErr.LockFile)
+	}
+
+	if err := lock.unlock(); err != nil {
+		t.Fatalf("unlock() error = %v", err)
+	}
+
+	lock, err = second.acquireLock(context.Background())
+	if err != nil {
+		t.Fatalf("acquireLock() after unlock error = %v", err)
+	}
+	lock.unlock()
+}
+
+func TestUpdater_acquireLock_waitsForRelease(t *testing.T) {
+	metadataFile := filepath.Join(t.TempDir(), "metadata.json")
+	first := mustNewUpdater(t, UpdaterConfig{MetadataFile: metadataFile})
+	second := mustNewUpdater(t, UpdaterConfig{MetadataFile: metadataFile, LockTimeout: 5 * time.Second})
+
+	held, err := first.acquireLock(context.Background())
+	if err != nil {
+		t.Fatalf("acquireLock() error = %v", err)
+	}
+	time.AfterFunc(100*time.Millisecond, func() { held.unlock() })
+
+	lock, err := second.acquireLock(context.Background())
+	if err != nil {
+		t.Fatalf("acquireLock() error = %v, want lock after release", err)
+	}
+	lock.unlock()
+}
+
+func TestUpdater_acquireLock_contextCanceled(t *testing.T) {
+	metadataFile := filepath.Join(t.TempDir(), "metadata.json")
+	first := mustNewUpdater(t, UpdaterConfig{MetadataFile: metadataFile})
+	second := mustNewUpdater(t, UpdaterConfig{MetadataFile: metadataFile})
+
+	lock, err := first.acquireLock(context.Background())
+	if err != nil {
+		t.Fatalf("acquireLock() error = %v", err)
+	}
+	defer lock.unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := second.acquireLock(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("acquireLock() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestUpdater_acquireLock_noMetadataFile(t *testing.T) {
+	updater := mustNewUpdater(t, UpdaterConfig{})
+	lock, err := updater.acquireLock(context.Background())
+	if err != nil {
+		t.Fatalf("acquireLock() error = %v", err)
+	}
+	if err := lock.unlock(); err != nil {
+		t.Errorf("unlock() error = %v", err)
+	}
+}
+
+func TestUpdater_UpdateWithResult_locked(t *testing.T) {
+	tmpDir := t.TempDir()
+	metadataFile := filepath.Join(tmpDir, "metadata.json")
+
+	holder := mustNewUpdater(t, UpdaterConfig{MetadataFile: metadataFile})
+	lock, err := holder.acquireLock(context.Background())
+	if err != nil {
+		t.Fatalf("acquireLock() error = %v", err)
+	}
+	defer lock.unlock()
+
+	updater := mustNewUpdater(t, UpdaterConfig{MetadataFile: metadataFile, LockTimeout: 50 * time.Millisecond})
+	if _, err := updater.UpdateWithResult(context.Background()); !errors.Is(err, ErrUpdateInProgress) {
+		t.Errorf("UpdateWithResult() error = %v, want ErrUpdateInProgress", err)
+	}
+}
+
+func TestLockExclusive(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Skipf("hostname unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		owner   *LockOwner
+		wantErr bool
+	}{
+		{name: "free", owner: nil},
+		{name: "stale owner on this host", owner: &LockOwner{PID: deadPID(t), Hostname: hostname}},
+		{name: "live owner on this host", owner: &LockOwner{PID: os.Getpid(), Hostname: hostname}, wantErr: true},
+		{name: "owner on another host", owner: &LockOwner{PID: deadPID(t), Hostname: hostname + "-other"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "metadata.json.lock")
+			if tt.owner != nil {
+				writeLockOwner(t, path, *tt.owner)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			lock, err := lockExclusive(ctx, path)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUpdateInProgress) {
+					t.Errorf("lockExclusive() error = %v, want ErrUpdateInProgress", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("lockExclusive() error = %v", err)
+			}
+
+			owner, err := readLockOwner(path)
+			if err != nil || owner.PID != os.Getpid() {
+				t.Errorf("lock owner = %+v (%v), want current pid", owner, err)
+			}
+			if err := lock.unlock(); err != nil {
+				t.Fatalf("unlock() error = %v", err)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Error("unlock() should remove the lock file")
+			}
+		})
+	}
+}
+
+func TestLockOwner_isStale(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Skipf("hostname unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		owner LockOwner
+		want  bool
+	}{
+		{name: "dead process", owner: LockOwner{PID: deadPID(t), Hostname: hostname}, want: true},
+		{name: "live process", owner: LockOwner{PID: os.Getpid(), Hostname: hostname}},
+		{name: "other host", owner: LockOwner{PID: deadPID(t), Hostname: "elsewhere"}},
+		{name: "invalid pid", owner: LockOwner{PID: 0, Hostname: hostname}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.owner.isStale(); got != tt.want {
+				t.Errorf("isStale() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
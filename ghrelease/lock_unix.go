@@ -0,0 +1,59 @@
+//go:build unix
+
+package ghrelease
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"syscall"
+)
+
+type flockLock struct {
+	file *os.File
+}
+
+func lockPath(ctx context.Context, path string) (fileLock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, defaultFilePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	err = waitLock(ctx, path, func() (bool, error) {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	data, err := json.Marshal(currentLockOwner())
+	if err == nil {
+		if err = f.Truncate(0); err == nil {
+			_, err = f.WriteAt(data, 0)
+		}
+	}
+	if err != nil {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+		return nil, err
+	}
+
+	return &flockLock{file: f}, nil
+}
+
+func (l *flockLock) unlock() error {
+	defer l.file.Close()
+	l.file.Truncate(0)
+	return syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+}
+
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,259 @@
+package ghrelease
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const defaultManifestChannel = "stable"
+
+var manifestVersionPattern = regexp.MustCompile(`v?\d+(\.\d+)*(-[A-Za-z][0-9A-Za-z.]*)?$`)
+
+func NewManifestSource(manifestURL string) *ManifestSource {
+	return &ManifestSource{URL: manifestURL}
+}
+
+func (s *ManifestSource) ListReleases(ctx context.Context) ([]*Release, error) {
+	manifest, err := s.fetchManifest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []*Release
+	for _, r := range manifest.Releases {
+		if s.inChannel(r) {
+			release, err := s.newRelease(r)
+			if err != nil {
+				return nil, err
+			}
+			releases = append(releases, release)
+		}
+	}
+	sort.SliceStable(releases, func(i, j int) bool {
+		return CompareVersions(releases[i].Tag, releases[j].Tag) > 0
+	})
+	return releases, nil
+}
+
+func (s *ManifestSource) LatestRelease(ctx context.Context) (*Release, error) {
+	if s.Version != "" {
+		return s.ReleaseByTag(ctx, s.Version)
+	}
+
+	releases, err := s.ListReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(releases) == 0 {
+		return nil, fmt.Errorf("manifest has no releases in channel %q", s.channel())
+	}
+	return releases[0], nil
+}
+
+func (s *ManifestSource) ReleaseByTag(ctx context.Context, tag string) (*Release, error) {
+	manifest, err := s.fetchManifest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range manifest.Releases {
+		if r.Version == tag {
+			return s.newRelease(r)
+		}
+	}
+	return nil, fmt.Errorf("manifest has no release %s", tag)
+}
+
+func (s *ManifestSource) OpenArchive(ctx context.Context, release *Release) (io.ReadCloser, error) {
+	if release.ArchiveURL == "" {
+		return nil, fmt.Errorf("release %s has no archive url", release.Tag)
+	}
+	return openLocation(ctx, s.httpClient(), release.ArchiveURL)
+}
+
+func (s *ManifestSource) OpenAsset(ctx context.Context, asset Asset) (io.ReadCloser, error) {
+	if asset.DownloadURL == "" {
+		return nil, fmt.Errorf("asset %s has no download url", asset.Name)
+	}
+	return openLocation(ctx, s.httpClient(), asset.DownloadURL)
+}
+
+func (s *ManifestSource) fetchManifest(ctx context.Context) (*Manifest, error) {
+	if s.URL == "" {
+		return nil, fmt.Errorf("manifest url cannot be empty")
+	}
+
+	rc, err := openLocation(ctx, s.httpClient(), s.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest: %w", err)
+	}
+	defer rc.Close()
+
+	var manifest Manifest
+	if err := json.NewDecoder(rc).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+func (s *ManifestSource) newRelease(r ManifestRelease) (*Release, error) {
+	archiveURL, err := s.resolve(r.Archive.URL)
+	if err != nil {
+		return nil, fmt.Errorf("release %s: %w", r.Version, err)
+	}
+
+	release := &Release{
+		Tag:           r.Version,
+		Name:          r.Name,
+		Body:          r.Notes,
+		PublishedAt:   r.PublishedAt,
+		Prerelease:    manifestChannel(r.Channel) != defaultManifestChannel,
+		ArchiveURL:    archiveURL,
+		ArchiveSHA256: r.Archive.SHA256,
+		FlatArchive:   r.Archive.Flat,
+	}
+	for _, a := range r.Assets {
+		downloadURL, err := s.resolve(a.URL)
+		if err != nil {
+			return nil, fmt.Errorf("release %s asset %s: %w", r.Version, a.Name, err)
+		}
+		release.Assets = append(release.Assets, Asset{
+			Name:        a.Name,
+			Size:        a.Size,
+			DownloadURL: downloadURL,
+			SHA256:      a.SHA256,
+		})
+	}
+	return release, nil
+}
+
+func (s *ManifestSource) resolve(ref string) (string, error) {
+	if ref == "" {
+		return "", nil
+	}
+	base, err := url.Parse(s.URL)
+	if err != nil {
+		return "", err
+	}
+	target, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(target).String(), nil
+}
+
+func (s *ManifestSource) inChannel(r ManifestRelease) bool {
+	return manifestChannel(r.Channel) == s.channel()
+}
+
+func (s *ManifestSource) channel() string {
+	return manifestChannel(s.Channel)
+}
+
+func (s *ManifestSource) httpClient() *http.Client {
+	return httpClientOrDefault(s.HTTPClient)
+}
+
+func manifestChannel(channel string) string {
+	if channel == "" {
+		return defaultManifestChannel
+	}
+	return channel
+}
+
+func GenerateManifest(dir string) (*Manifest, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{}
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		base, ok := trimArchiveExt(name)
+		if dirEntry.IsDir() || !ok {
+			continue
+		}
+		version := manifestVersionPattern.FindString(base)
+		if version == "" {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			return nil, err
+		}
+		flat, err := isFlatArchive(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		release := ManifestRelease{
+			Version:     version,
+			PublishedAt: info.ModTime().UTC(),
+			Archive: ManifestFile{
+				URL:    (&url.URL{Path: name}).String(),
+				SHA256: hashBytes(data),
+				Size:   int64(len(data)),
+				Flat:   flat,
+			},
+		}
+		if _, pre, _ := strings.Cut(trimVersion(version), "-"); pre != "" {
+			release.Channel = strings.SplitN(pre, ".", 2)[0]
+		}
+		manifest.Releases = append(manifest.Releases, release)
+	}
+
+	sort.SliceStable(manifest.Releases, func(i, j int) bool {
+		return CompareVersions(manifest.Releases[i].Version, manifest.Releases[j].Version) > 0
+	})
+	return manifest, nil
+}
+
+func trimArchiveExt(name string) (string, bool) {
+	for _, ext := range []string{".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(name, ext) {
+			return strings.TrimSuffix(name, ext), true
+		}
+	}
+	return "", false
+}
+
+func isFlatArchive(data []byte) (bool, error) {
+	read := readZip
+	if isGzip(data) {
+		read = readTarGz
+	}
+	entries, err := read(data)
+	if err != nil {
+		return false, err
+	}
+
+	root := ""
+	for _, entry := range entries {
+		name := strings.TrimPrefix(entry.path, "./")
+		dir, _, nested := strings.Cut(name, "/")
+		if !nested {
+			return true, nil
+		}
+		if root != "" && dir != root {
+			return true, nil
+		}
+		root = dir
+	}
+	return false, nil
+}
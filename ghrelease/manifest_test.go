@@ -0,0 +1,250 @@
+package ghrelease
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTestManifest(t *testing.T, dir string, manifest *Manifest) string {
+	t.Helper()
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		t.Fatalf("marshal manifest: %v", err)
+	}
+	path := filepath.Join(dir, "manifest.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+	return path
+}
+
+func newTestManifestDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	archives := map[string][]byte{
+		"pack-v1.0.0.zip":        createTestZip(t, map[string]string{"pack/agents/a.md": "a1"}),
+		"pack-v1.1.0.tar.gz":     createTestTarGz(t, map[string]string{"README.md": "readme", "agents/a.md": "a2", "agents/b.md": "b2"}),
+		"pack-v1.2.0-beta.1.zip": createTestZip(t, map[string]string{"pack/agents/a.md": "a3"}),
+		"notes.txt":              []byte("not an archive"),
+	}
+	for name, data := range archives {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestGenerateManifest(t *testing.T) {
+	dir := newTestManifestDir(t)
+
+	manifest, err := GenerateManifest(dir)
+	if err != nil {
+		t.Fatalf("GenerateManifest() error = %v", err)
+	}
+
+	var versions []string
+	for _, release := range manifest.Releases {
+		versions = append(versions, release.Version)
+	}
+	if want := []string{"v1.2.0-beta.1", "v1.1.0", "v1.0.0"}; !reflect.DeepEqual(versions, want) {
+		t.Fatalf("versions = %v, want %v", versions, want)
+	}
+
+	beta, stable, old := manifest.Releases[0], manifest.Releases[1], manifest.Releases[2]
+	if beta.Channel != "beta" || stable.Channel != "" {
+		t.Errorf("channels = %q, %q", beta.Channel, stable.Channel)
+	}
+	if !stable.Archive.Flat || old.Archive.Flat {
+		t.Errorf("flat = %v, %v", stable.Archive.Flat, old.Archive.Flat)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "pack-v1.0.0.zip"))
+	if old.Archive.URL != "pack-v1.0.0.zip" || old.Archive.SHA256 != hashBytes(data) || old.Archive.Size != int64(len(data)) {
+		t.Errorf("archive = %+v", old.Archive)
+	}
+	if old.PublishedAt.IsZero() {
+		t.Error("PublishedAt should be set from the file mtime")
+	}
+
+	if _, err := GenerateManifest(filepath.Join(dir, "missing")); err == nil {
+		t.Error("GenerateManifest() on a missing dir should fail")
+	}
+}
+
+func TestManifestSource(t *testing.T) {
+	dir := newTestManifestDir(t)
+	manifest, err := GenerateManifest(dir)
+	if err != nil {
+		t.Fatalf("GenerateManifest() error = %v", err)
+	}
+	manifest.Releases[1].Notes = "notes for v1.1.0"
+	manifest.Releases[1].Assets = []ManifestAsset{{Name: "notes.txt", URL: "notes.txt", SHA256: "abc"}}
+	writeTestManifest(t, dir, manifest)
+
+	server := httptest.NewServer(http.StripPrefix("/mirror", http.FileServer(http.Dir(dir))))
+	t.Cleanup(server.Close)
+
+	locations := map[string]string{
+		"http": server.URL + "/mirror/manifest.json",
+		"file": fileURL(filepath.Join(dir, "manifest.json")),
+	}
+	for name, location := range locations {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			source := NewManifestSource(location)
+
+			releases, err := source.ListReleases(ctx)
+			if err != nil {
+				t.Fatalf("ListReleases() error = %v", err)
+			}
+			if len(releases) != 2 || releases[0].Tag != "v1.1.0" || releases[1].Tag != "v1.0.0" {
+				t.Fatalf("ListReleases() = %+v", releases)
+			}
+
+			latest, err := source.LatestRelease(ctx)
+			if err != nil {
+				t.Fatalf("LatestRelease() error = %v", err)
+			}
+			if latest.Tag != "v1.1.0" || latest.Body != "notes for v1.1.0" || !latest.FlatArchive || latest.Prerelease {
+				t.Errorf("LatestRelease() = %+v", latest)
+			}
+			if want := strings.TrimSuffix(location, "manifest.json") + "pack-v1.1.0.tar.gz"; latest.ArchiveURL != want {
+				t.Errorf("ArchiveURL = %q, want %q", latest.ArchiveURL, want)
+			}
+			if len(latest.Assets) != 1 || latest.Assets[0].SHA256 != "abc" {
+				t.Fatalf("Assets = %+v", latest.Assets)
+			}
+
+			rc, err := source.OpenAsset(ctx, latest.Assets[0])
+			if err != nil {
+				t.Fatalf("OpenAsset() error = %v", err)
+			}
+			data, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil || string(data) != "not an archive" {
+				t.Errorf("OpenAsset() = %q, %v", data, err)
+			}
+
+			source.Channel = "beta"
+			beta, err := source.LatestRelease(ctx)
+			if err != nil {
+				t.Fatalf("LatestRelease(beta) error = %v", err)
+			}
+			if beta.Tag != "v1.2.0-beta.1" || !beta.Prerelease {
+				t.Errorf("LatestRelease(beta) = %+v", beta)
+			}
+
+			source.Channel = ""
+			source.Version = "v1.0.0"
+			pinned, err := source.LatestRelease(ctx)
+			if err != nil {
+				t.Fatalf("LatestRelease(pinned) error = %v", err)
+			}
+			if pinned.Tag != "v1.0.0" {
+				t.Errorf("LatestRelease(pinned) = %+v", pinned)
+			}
+		})
+	}
+}
+
+func TestManifestSource_errors(t *testing.T) {
+	dir := t.TempDir()
+	writeTestManifest(t, dir, &Manifest{Releases: []ManifestRelease{
+		{Version: "v1.0.0", Channel: "beta", Archive: ManifestFile{URL: "pack.zip"}},
+	}})
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		source *ManifestSource
+	}{
+		{name: "empty url", source: &ManifestSource{}},
+		{name: "missing file", source: NewManifestSource(fileURL(filepath.Join(dir, "missing.json")))},
+		{name: "invalid json", source: NewManifestSource(fileURL(filepath.Join(dir, "broken.json")))},
+		{name: "unsupported scheme", source: NewManifestSource("ftp://example.com/manifest.json")},
+		{name: "no releases in channel", source: NewManifestSource(fileURL(filepath.Join(dir, "manifest.json")))},
+		{name: "unknown pinned version", source: &ManifestSource{URL: fileURL(filepath.Join(dir, "manifest.json")), Version: "v9.9.9"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.source.LatestRelease(context.Background()); err == nil {
+				t.Error("LatestRelease() should fail")
+			}
+		})
+	}
+}
+
+func TestUpdater_manifestSource(t *testing.T) {
+	dir := newTestManifestDir(t)
+	manifest, err := GenerateManifest(dir)
+	if err != nil {
+		t.Fatalf("GenerateManifest() error = %v", err)
+	}
+	manifestPath := writeTestManifest(t, dir, manifest)
+
+	newUpdater := func(t *testing.T, version string) (*Updater, string) {
+		tmpDir := t.TempDir()
+		agentsDir := filepath.Join(tmpDir, "agents")
+		source := NewManifestSource(fileURL(manifestPath))
+		source.Version = version
+		return mustNewUpdater(t, UpdaterConfig{
+			Source:       source,
+			MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+			Targets:      []ExtractTarget{{PathTransformer: &SubDirTransformer{SubDir: "agents"}, DestDir: agentsDir}},
+		}), agentsDir
+	}
+
+	tests := []struct {
+		name    string
+		version string
+		want    map[string]string
+	}{
+		{name: "latest flat tar.gz", want: map[string]string{"a.md": "a2", "b.md": "b2"}},
+		{name: "pinned zip", version: "v1.0.0", want: map[string]string{"a.md": "a1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updater, agentsDir := newUpdater(t, tt.version)
+			if _, err := updater.UpdateWithResult(context.Background()); err != nil {
+				t.Fatalf("UpdateWithResult() error = %v", err)
+			}
+			for name, want := range tt.want {
+				content, err := os.ReadFile(filepath.Join(agentsDir, name))
+				if err != nil || string(content) != want {
+					t.Errorf("%s = %q, %v, want %q", name, content, err, want)
+				}
+			}
+		})
+	}
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		manifest.Releases[1].Archive.SHA256 = strings.Repeat("0", 64)
+		manifest.Releases[1].PublishedAt = time.Now()
+		writeTestManifest(t, dir, manifest)
+
+		updater, agentsDir := newUpdater(t, "")
+		_, err := updater.UpdateWithResult(context.Background())
+		if err == nil || !strings.Contains(err.Error(), "archive checksum mismatch") {
+			t.Fatalf("UpdateWithResult() error = %v, want checksum mismatch", err)
+		}
+		if _, err := os.Stat(agentsDir); !os.IsNotExist(err) {
+			t.Errorf("agents dir should not be created on checksum mismatch")
+		}
+	})
+}
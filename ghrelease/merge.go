@@ -0,0 +1,91 @@
+package ghrelease
+
+import (
+	"strings"
+)
+
+const (
+	mergeMarkerOurs   = "<<<<<<< local\n"
+	mergeMarkerSep    = "=======\n"
+	mergeMarkerTheirs = ">>>>>>> upstream\n"
+)
+
+func merge3(base, ours, theirs []byte) ([]byte, int) {
+	baseLines := splitLines(base)
+	ourLines := splitLines(ours)
+	theirLines := splitLines(theirs)
+
+	ourMatch := matchLines(baseLines, ourLines)
+	theirMatch := matchLines(baseLines, theirLines)
+
+	var out strings.Builder
+	conflicts := 0
+	i, j, k := 0, 0, 0
+
+	for i < len(baseLines) || j < len(ourLines) || k < len(theirLines) {
+		n := 0
+		for i+n < len(baseLines) && ourMatch[i+n] == j+n && theirMatch[i+n] == k+n {
+			n++
+		}
+		if n > 0 {
+			writeLines(&out, baseLines[i:i+n])
+			i, j, k = i+n, j+n, k+n
+			continue
+		}
+
+		nextI, nextJ, nextK := len(baseLines), len(ourLines), len(theirLines)
+		for x := i; x < len(baseLines); x++ {
+			if ourMatch[x] >= 0 && theirMatch[x] >= 0 {
+				nextI, nextJ, nextK = x, ourMatch[x], theirMatch[x]
+				break
+			}
+		}
+
+		baseChunk := baseLines[i:nextI]
+		ourChunk := ourLines[j:nextJ]
+		theirChunk := theirLines[k:nextK]
+
+		switch {
+		case equalLines(ourChunk, baseChunk):
+			writeLines(&out, theirChunk)
+		case equalLines(theirChunk, baseChunk), equalLines(ourChunk, theirChunk):
+			writeLines(&out, ourChunk)
+		default:
+			conflicts++
+			out.WriteString(mergeMarkerOurs)
+			writeConflictLines(&out, ourChunk)
+			out.WriteString(mergeMarkerSep)
+			writeConflictLines(&out, theirChunk)
+			out.WriteString(mergeMarkerTheirs)
+		}
+
+		i, j, k = nextI, nextJ, nextK
+	}
+
+	return []byte(out.String()), conflicts
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func writeLines(out *strings.Builder, lines []string) {
+	for _, line := range lines {
+		out.WriteString(line)
+	}
+}
+
+func writeConflictLines(out *strings.Builder, lines []string) {
+	writeLines(out, lines)
+	if len(lines) > 0 && !strings.HasSuffix(lines[len(lines)-1], "\n") {
+		out.WriteString("\n")
+	}
+}
@@ -0,0 +1,218 @@
+package ghrelease
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		name          string
+		base          string
+		ours          string
+		theirs        string
+		want          string
+		wantConflicts int
+	}{
+		{
+			name:   "no changes",
+			base:   "a\nb\nc\n",
+			ours:   "a\nb\nc\n",
+			theirs: "a\nb\nc\n",
+			want:   "a\nb\nc\n",
+		},
+		{
+			name:   "only upstream changed",
+			base:   "a\nb\nc\n",
+			ours:   "a\nb\nc\n",
+			theirs: "a\nB\nc\n",
+			want:   "a\nB\nc\n",
+		},
+		{
+			name:   "only local changed",
+			base:   "a\nb\nc\n",
+			ours:   "a\nb\nC\n",
+			theirs: "a\nb\nc\n",
+			want:   "a\nb\nC\n",
+		},
+		{
+			name:   "non-overlapping changes",
+			base:   "a\nb\nc\nd\ne\n",
+			ours:   "A\nb\nc\nd\ne\n",
+			theirs: "a\nb\nc\nd\nE\n",
+			want:   "A\nb\nc\nd\nE\n",
+		},
+		{
+			name:   "local insertion and upstream deletion",
+			base:   "a\nb\nc\nd\n",
+			ours:   "a\nlocal\nb\nc\nd\n",
+			theirs: "a\nb\nc\n",
+			want:   "a\nlocal\nb\nc\n",
+		},
+		{
+			name:   "identical changes on both sides",
+			base:   "a\nb\nc\n",
+			ours:   "a\nX\nc\n",
+			theirs: "a\nX\nc\n",
+			want:   "a\nX\nc\n",
+		},
+		{
+			name:          "overlapping changes",
+			base:          "a\nb\nc\n",
+			ours:          "a\nmine\nc\n",
+			theirs:        "a\ntheirs\nc\n",
+			want:          "a\n<<<<<<< local\nmine\n=======\ntheirs\n>>>>>>> upstream\nc\n",
+			wantConflicts: 1,
+		},
+		{
+			name:          "conflict without trailing newline",
+			base:          "a\nb",
+			ours:          "a\nmine",
+			theirs:        "a\ntheirs",
+			want:          "a\n<<<<<<< local\nmine\n=======\ntheirs\n>>>>>>> upstream\n",
+			wantConflicts: 1,
+		},
+		{
+			name:   "empty base",
+			base:   "",
+			ours:   "",
+			theirs: "new\n",
+			want:   "new\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := merge3([]byte(tt.base), []byte(tt.ours), []byte(tt.theirs))
+			if string(got) != tt.want {
+				t.Errorf("merge3() = %q, want %q", string(got), tt.want)
+			}
+			if conflicts != tt.wantConflicts {
+				t.Errorf("merge3() conflicts = %d, want %d", conflicts, tt.wantConflicts)
+			}
+		})
+	}
+}
+
+func TestUpdater_installFile_merge(t *testing.T) {
+	tests := []struct {
+		name          string
+		fallback      ConflictPolicy
+		base          string
+		local         string
+		upstream      string
+		storeBase     bool
+		wantContent   string
+		wantStatus    MergeStatus
+		wantConflicts int
+	}{
+		{
+			name:        "clean merge",
+			base:        "a\nb\nc\n",
+			local:       "A\nb\nc\n",
+			upstream:    "a\nb\nC\n",
+			storeBase:   true,
+			wantContent: "A\nb\nC\n",
+			wantStatus:  MergeClean,
+		},
+		{
+			name:          "conflict markers",
+			base:          "a\nb\n",
+			local:         "a\nmine\n",
+			upstream:      "a\ntheirs\n",
+			storeBase:     true,
+			wantContent:   "a\n<<<<<<< local\nmine\n=======\ntheirs\n>>>>>>> upstream\n",
+			wantStatus:    MergeConflicts,
+			wantConflicts: 1,
+		},
+		{
+			name:          "conflict with fallback",
+			fallback:      ConflictOverwrite,
+			base:          "a\nb\n",
+			local:         "a\nmine\n",
+			upstream:      "a\ntheirs\n",
+			storeBase:     true,
+			wantContent:   "a\ntheirs\n",
+			wantStatus:    MergeFallback,
+			wantConflicts: 1,
+		},
+		{
+			name:        "missing base keeps local",
+			base:        "a\nb\n",
+			local:       "a\nmine\n",
+			upstream:    "a\ntheirs\n",
+			wantContent: "a\nmine\n",
+			wantStatus:  MergeFallback,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			destDir := filepath.Join(tmpDir, "dest")
+			path := filepath.Join(destDir, "prompt.md")
+			writeTestFiles(t, map[string]string{path: tt.local})
+
+			target := ExtractTarget{
+				PathTransformer: &KeepAllTransformer{},
+				DestDir:         destDir,
+				ConflictPolicy:  ConflictMerge,
+				MergeFallback:   tt.fallback,
+			}
+			updater := mustNewUpdater(t, UpdaterConfig{
+				MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+				Targets:      []ExtractTarget{target},
+			})
+			if tt.storeBase {
+				if err := updater.pristine.put([]byte(tt.base)); err != nil {
+					t.Fatalf("put() error = %v", err)
+				}
+			}
+
+			result := &UpdateResult{}
+			_, err := updater.installFile(target, path, []byte(tt.upstream), hashBytes([]byte(tt.base)), result)
+			if err != nil {
+				t.Fatalf("installFile() error = %v", err)
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", path, err)
+			}
+			if string(content) != tt.wantContent {
+				t.Errorf("content = %q, want %q", string(content), tt.wantContent)
+			}
+
+			if len(result.Merges) != 1 {
+				t.Fatalf("Merges = %+v, want one entry", result.Merges)
+			}
+			if result.Merges[0].Status != tt.wantStatus {
+				t.Errorf("Status = %v, want %v", result.Merges[0].Status, tt.wantStatus)
+			}
+			if result.Merges[0].Conflicts != tt.wantConflicts {
+				t.Errorf("Conflicts = %d, want %d", result.Merges[0].Conflicts, tt.wantConflicts)
+			}
+
+			if _, err := updater.pristine.get(hashBytes([]byte(tt.upstream))); err != nil {
+				t.Errorf("upstream content should be stored as pristine: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewUpdater_mergeRequiresPristineDir(t *testing.T) {
+	target := ExtractTarget{PathTransformer: &KeepAllTransformer{}, DestDir: "/tmp", ConflictPolicy: ConflictMerge}
+
+	if _, err := NewUpdater(UpdaterConfig{RepoOwner: "owner", RepoName: "repo", Targets: []ExtractTarget{target}}); err == nil {
+		t.Error("NewUpdater() should return error without pristine dir or metadata file")
+	}
+
+	updater := mustNewUpdater(t, UpdaterConfig{
+		MetadataFile: "/data/metadata.json",
+		Targets:      []ExtractTarget{target},
+	})
+	if want := filepath.Join("/data", defaultPristineDir); updater.config.PristineDir != want {
+		t.Errorf("PristineDir = %q, want %q", updater.config.PristineDir, want)
+	}
+}
@@ -0,0 +1,460 @@
+package ghrelease
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ociTagPageSize       = 100
+	ociTitleAnnotation   = "org.opencontainers.image.title"
+	ociCreatedAnnotation = "org.opencontainers.image.created"
+	ociDescAnnotation    = "org.opencontainers.image.description"
+	ociWhiteoutPrefix    = ".wh."
+	ociOpaqueWhiteout    = ".wh..wh..opq"
+)
+
+var ociManifestMediaTypes = []string{
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+type ociManifest struct {
+	MediaType   string            `json:"mediaType"`
+	Layers      []ociDescriptor   `json:"layers"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+func NewOCISource(baseURL, repository string) *OCISource {
+	return &OCISource{BaseURL: baseURL, Repository: repository}
+}
+
+func (s *OCISource) ListReleases(ctx context.Context) ([]*Release, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+
+	var releases []*Release
+	next := s.repoURL("/tags/list") + "?" + url.Values{"n": {strconv.Itoa(ociTagPageSize)}}.Encode()
+	for next != "" {
+		resp, err := s.get(ctx, next, "application/json")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tags: %w", err)
+		}
+
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse tags: %w", err)
+		}
+
+		for _, tag := range page.Tags {
+			if manifestVersionPattern.FindString(tag) == tag {
+				releases = append(releases, s.newRelease(tag))
+			}
+		}
+		if next, err = nextLink(next, resp.Header.Get("Link")); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(releases, func(i, j int) bool {
+		return CompareVersions(releases[i].Tag, releases[j].Tag) > 0
+	})
+	return releases, nil
+}
+
+func (s *OCISource) LatestRelease(ctx context.Context) (*Release, error) {
+	releases, err := s.ListReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := latestStable(releases, s.Repository)
+	if err != nil {
+		return nil, err
+	}
+	return s.ReleaseByTag(ctx, latest.Tag)
+}
+
+func (s *OCISource) ReleaseByTag(ctx context.Context, tag string) (*Release, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+
+	manifest, err := s.fetchManifest(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	release := s.newRelease(tag)
+	release.Body = manifest.Annotations[ociDescAnnotation]
+	if created, err := time.Parse(time.RFC3339, manifest.Annotations[ociCreatedAnnotation]); err == nil {
+		release.PublishedAt = created
+	}
+	for _, layer := range manifest.Layers {
+		name := layer.Annotations[ociTitleAnnotation]
+		if name == "" {
+			continue
+		}
+		_, sum, _ := strings.Cut(layer.Digest, ":")
+		release.Assets = append(release.Assets, Asset{
+			Name:        name,
+			Size:        layer.Size,
+			ContentType: layer.MediaType,
+			DownloadURL: s.repoURL("/blobs/" + layer.Digest),
+			SHA256:      sum,
+		})
+	}
+	return release, nil
+}
+
+func (s *OCISource) OpenArchive(ctx context.Context, release *Release) (io.ReadCloser, error) {
+	manifest, err := s.fetchManifest(ctx, release.Tag)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	for _, layer := range manifest.Layers {
+		data, err := s.fetchBlob(ctx, layer)
+		if err != nil {
+			return nil, err
+		}
+		if err := applyLayer(files, layer, data); err != nil {
+			return nil, fmt.Errorf("layer %s: %w", layer.Digest, err)
+		}
+	}
+
+	data, err := zipFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *OCISource) OpenAsset(ctx context.Context, asset Asset) (io.ReadCloser, error) {
+	if asset.DownloadURL == "" {
+		return nil, fmt.Errorf("asset %s has no download url", asset.Name)
+	}
+	resp, err := s.get(ctx, asset.DownloadURL, "")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *OCISource) newRelease(tag string) *Release {
+	return &Release{
+		Tag:         tag,
+		Name:        s.Repository + ":" + tag,
+		Prerelease:  isPrerelease(tag),
+		ArchiveURL:  s.repoURL("/manifests/" + url.PathEscape(tag)),
+		FlatArchive: true,
+	}
+}
+
+func (s *OCISource) fetchManifest(ctx context.Context, tag string) (*ociManifest, error) {
+	resp, err := s.get(ctx, s.repoURL("/manifests/"+url.PathEscape(tag)), strings.Join(ociManifestMediaTypes, ", "))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest %s: %w", tag, err)
+	}
+	defer resp.Body.Close()
+
+	var manifest ociManifest
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", tag, err)
+	}
+	if manifest.MediaType == "" {
+		manifest.MediaType = resp.Header.Get("Content-Type")
+	}
+	if !contains(ociManifestMediaTypes, manifest.MediaType) {
+		return nil, fmt.Errorf("unsupported manifest media type %q for %s", manifest.MediaType, tag)
+	}
+	return &manifest, nil
+}
+
+func (s *OCISource) fetchBlob(ctx context.Context, desc ociDescriptor) ([]byte, error) {
+	resp, err := s.get(ctx, s.repoURL("/blobs/"+desc.Digest), "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob %s: %w", desc.Digest, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if desc.Size > 0 && int64(len(data)) != desc.Size {
+		return nil, fmt.Errorf("blob %s size mismatch: got %d, want %d", desc.Digest, len(data), desc.Size)
+	}
+	if err := verifyDigest(desc.Digest, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *OCISource) get(ctx context.Context, rawURL, accept string) (*http.Response, error) {
+	resp, err := s.do(ctx, rawURL, accept, s.authorization())
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		auth, err := s.authorize(ctx, challenge)
+		if err != nil {
+			return nil, fmt.Errorf("authorize: %w", err)
+		}
+		if resp, err = s.do(ctx, rawURL, accept, auth); err != nil {
+			return nil, err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, rawURL)
+	}
+	return resp, nil
+}
+
+func (s *OCISource) do(ctx context.Context, rawURL, accept, auth string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if auth != "" && sameHost(rawURL, s.BaseURL) {
+		req.Header.Set("Authorization", auth)
+	}
+	return s.httpClient().Do(req)
+}
+
+func (s *OCISource) authorization() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.auth == "" && s.Token != "" {
+		return "Bearer " + s.Token
+	}
+	return s.auth
+}
+
+func (s *OCISource) authorize(ctx context.Context, challenge string) (string, error) {
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if s.Username == "" {
+			return "", fmt.Errorf("registry requires credentials")
+		}
+		return s.setAuth("Basic " + s.basicAuth()), nil
+	case "bearer":
+	default:
+		return "", fmt.Errorf("unsupported auth challenge %q", challenge)
+	}
+
+	realm := params["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("bearer challenge has no realm")
+	}
+	query := url.Values{}
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	tokenURL := realm
+	if len(query) > 0 {
+		tokenURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	if s.Username != "" {
+		req.Header.Set("Authorization", "Basic "+s.basicAuth())
+	}
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, realm)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to parse token: %w", err)
+	}
+	token := body.Token
+	if token == "" {
+		token = body.AccessToken
+	}
+	if token == "" {
+		return "", fmt.Errorf("token response is empty")
+	}
+
+	return s.setAuth("Bearer " + token), nil
+}
+
+func (s *OCISource) setAuth(auth string) string {
+	s.mu.Lock()
+	s.auth = auth
+	s.mu.Unlock()
+	return auth
+}
+
+func (s *OCISource) basicAuth() string {
+	return base64.StdEncoding.EncodeToString([]byte(s.Username + ":" + s.Password))
+}
+
+func (s *OCISource) validate() error {
+	if s.BaseURL == "" {
+		return fmt.Errorf("registry url cannot be empty")
+	}
+	if s.Repository == "" {
+		return fmt.Errorf("repository cannot be empty")
+	}
+	return nil
+}
+
+func (s *OCISource) repoURL(path string) string {
+	return strings.TrimSuffix(s.BaseURL, "/") + "/v2/" + strings.Trim(s.Repository, "/") + path
+}
+
+func (s *OCISource) httpClient() *http.Client {
+	return httpClientOrDefault(s.HTTPClient)
+}
+
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := make(map[string]string)
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				params[key] = value[1:]
+				break
+			}
+			params[key] = value[1 : end+1]
+			rest = value[end+2:]
+		} else {
+			params[key], rest, _ = strings.Cut(value, ",")
+		}
+		rest = strings.TrimPrefix(strings.TrimSpace(rest), ",")
+	}
+	return scheme, params
+}
+
+func nextLink(current, link string) (string, error) {
+	for _, part := range strings.Split(link, ",") {
+		target, params, _ := strings.Cut(part, ";")
+		if !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		target = strings.Trim(strings.TrimSpace(target), "<>")
+
+		base, err := url.Parse(current)
+		if err != nil {
+			return "", err
+		}
+		ref, err := url.Parse(target)
+		if err != nil {
+			return "", err
+		}
+		return base.ResolveReference(ref).String(), nil
+	}
+	return "", nil
+}
+
+func verifyDigest(digest string, data []byte) error {
+	algorithm, want, _ := strings.Cut(digest, ":")
+	if algorithm != "sha256" {
+		return fmt.Errorf("unsupported digest algorithm %q", digest)
+	}
+	if got := hashBytes(data); got != want {
+		return fmt.Errorf("digest mismatch: got sha256:%s, want %s", got, digest)
+	}
+	return nil
+}
+
+func applyLayer(files map[string][]byte, layer ociDescriptor, data []byte) error {
+	if !strings.Contains(layer.MediaType, ".tar") {
+		if name := layer.Annotations[ociTitleAnnotation]; name != "" {
+			files[path.Clean(name)] = data
+		}
+		return nil
+	}
+
+	var entries []archiveEntry
+	var err error
+	if isGzip(data) {
+		entries, err = readTarGz(data)
+	} else {
+		entries, err = readTar(bytes.NewReader(data))
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		name := path.Clean(strings.TrimPrefix(entry.path, "./"))
+		dir, base := path.Split(name)
+		switch {
+		case base == ociOpaqueWhiteout:
+			removeLayerPrefix(files, dir)
+		case strings.HasPrefix(base, ociWhiteoutPrefix):
+			target := dir + strings.TrimPrefix(base, ociWhiteoutPrefix)
+			delete(files, target)
+			removeLayerPrefix(files, target+"/")
+		default:
+			content, err := entry.read()
+			if err != nil {
+				return err
+			}
+			files[name] = content
+		}
+	}
+	return nil
+}
+
+func removeLayerPrefix(files map[string][]byte, prefix string) {
+	for name := range files {
+		if strings.HasPrefix(name, prefix) {
+			delete(files, name)
+		}
+	}
+}
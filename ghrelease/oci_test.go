@@ -0,0 +1,328 @@
+package ghrelease
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	fakeRegistryUser  = "robot"
+	fakeRegistryPass  = "secret"
+	fakeRegistryToken = "registry-token"
+)
+
+type fakeLayer struct {
+	mediaType string
+	title     string
+	data      []byte
+}
+
+type fakeRegistry struct {
+	*httptest.Server
+	t *testing.T
+
+	mu        sync.Mutex
+	manifests map[string][]byte
+	blobs     map[string][]byte
+	corrupt   map[string]bool
+}
+
+func newFakeRegistry(t *testing.T) *fakeRegistry {
+	t.Helper()
+
+	registry := &fakeRegistry{
+		t:         t,
+		manifests: make(map[string][]byte),
+		blobs:     make(map[string][]byte),
+		corrupt:   make(map[string]bool),
+	}
+
+	const prefix = "/v2/team/pack"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != fakeRegistryUser || pass != fakeRegistryPass || r.URL.Query().Get("scope") != "repository:team/pack:pull" {
+			http.Error(w, "denied", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"token": fakeRegistryToken})
+	})
+	mux.HandleFunc(prefix+"/tags/list", func(w http.ResponseWriter, r *http.Request) {
+		registry.mu.Lock()
+		var tags []string
+		for tag := range registry.manifests {
+			tags = append(tags, tag)
+		}
+		registry.mu.Unlock()
+		sort.Strings(tags)
+
+		const pageSize = 2
+		last := r.URL.Query().Get("last")
+		start := sort.SearchStrings(tags, last)
+		if last != "" && start < len(tags) && tags[start] == last {
+			start++
+		}
+		end := min(start+pageSize, len(tags))
+		if end < len(tags) {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/tags/list?n=%d&last=%s>; rel="next"`, prefix, pageSize, tags[end-1]))
+		}
+		json.NewEncoder(w).Encode(map[string]any{"name": "team/pack", "tags": tags[start:end]})
+	})
+	mux.HandleFunc(prefix+"/manifests/", func(w http.ResponseWriter, r *http.Request) {
+		registry.mu.Lock()
+		manifest, ok := registry.manifests[strings.TrimPrefix(r.URL.Path, prefix+"/manifests/")]
+		registry.mu.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+		w.Write(manifest)
+	})
+	mux.HandleFunc(prefix+"/blobs/", func(w http.ResponseWriter, r *http.Request) {
+		digest := strings.TrimPrefix(r.URL.Path, prefix+"/blobs/")
+		registry.mu.Lock()
+		blob, ok := registry.blobs[digest]
+		corrupt := registry.corrupt[digest]
+		registry.mu.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if corrupt {
+			blob = append([]byte(nil), blob...)
+			blob[len(blob)-1] ^= 0xff
+		}
+		w.Write(blob)
+	})
+
+	registry.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v2/") && r.Header.Get("Authorization") != "Bearer "+fakeRegistryToken {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake-registry",scope="repository:team/pack:pull"`, registry.URL))
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(registry.Close)
+	return registry
+}
+
+func (r *fakeRegistry) push(tag string, annotations map[string]string, layers ...fakeLayer) {
+	r.t.Helper()
+
+	var descriptors []map[string]any
+	r.mu.Lock()
+	for _, layer := range layers {
+		digest := "sha256:" + hashBytes(layer.data)
+		r.blobs[digest] = layer.data
+		descriptor := map[string]any{"mediaType": layer.mediaType, "digest": digest, "size": len(layer.data)}
+		if layer.title != "" {
+			descriptor["annotations"] = map[string]string{ociTitleAnnotation: layer.title}
+		}
+		descriptors = append(descriptors, descriptor)
+	}
+	r.mu.Unlock()
+
+	manifest, err := json.Marshal(map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"config":        map[string]any{"mediaType": "application/vnd.oci.empty.v1+json", "digest": "sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a", "size": 2},
+		"layers":        descriptors,
+		"annotations":   annotations,
+	})
+	if err != nil {
+		r.t.Fatalf("marshal manifest: %v", err)
+	}
+
+	r.mu.Lock()
+	r.manifests[tag] = manifest
+	r.mu.Unlock()
+}
+
+func (r *fakeRegistry) corruptBlob(data []byte) {
+	r.mu.Lock()
+	r.corrupt["sha256:"+hashBytes(data)] = true
+	r.mu.Unlock()
+}
+
+func newTestOCISource(registry *fakeRegistry) *OCISource {
+	source := NewOCISource(registry.URL, "team/pack")
+	source.Username = fakeRegistryUser
+	source.Password = fakeRegistryPass
+	return source
+}
+
+func TestOCISource(t *testing.T) {
+	registry := newFakeRegistry(t)
+	base := createTestTarGz(t, map[string]string{"agents/a.md": "a2", "agents/old.md": "old", "skills/s.md": "s"})
+	overlay := createTestTarGz(t, map[string]string{"agents/b.md": "b2", "agents/.wh.old.md": "", "skills/.wh..wh..opq": ""})
+	for _, tag := range []string{"v1.0.0", "v1.2.0-rc1", "latest", "v0.9.0"} {
+		registry.push(tag, nil, fakeLayer{mediaType: "application/vnd.oci.image.layer.v1.tar+gzip", data: base})
+	}
+	registry.push("v1.1.0",
+		map[string]string{ociCreatedAnnotation: "2024-03-04T05:06:07Z", ociDescAnnotation: "second release"},
+		fakeLayer{mediaType: "application/vnd.oci.image.layer.v1.tar+gzip", data: base},
+		fakeLayer{mediaType: "application/vnd.oci.image.layer.v1.tar+gzip", data: overlay},
+		fakeLayer{mediaType: "text/markdown", title: "NOTES.md", data: []byte("notes")},
+	)
+
+	source := newTestOCISource(registry)
+	ctx := context.Background()
+
+	releases, err := source.ListReleases(ctx)
+	if err != nil {
+		t.Fatalf("ListReleases() error = %v", err)
+	}
+	var tags []string
+	for _, release := range releases {
+		tags = append(tags, release.Tag)
+	}
+	if want := []string{"v1.2.0-rc1", "v1.1.0", "v1.0.0", "v0.9.0"}; !reflect.DeepEqual(tags, want) {
+		t.Fatalf("ListReleases() tags = %v, want %v", tags, want)
+	}
+
+	latest, err := source.LatestRelease(ctx)
+	if err != nil {
+		t.Fatalf("LatestRelease() error = %v", err)
+	}
+	if latest.Tag != "v1.1.0" || latest.Body != "second release" || latest.PublishedAt.Year() != 2024 || !latest.FlatArchive {
+		t.Errorf("LatestRelease() = %+v", latest)
+	}
+	if len(latest.Assets) != 1 || latest.Assets[0].Name != "NOTES.md" || latest.Assets[0].SHA256 != hashBytes([]byte("notes")) {
+		t.Fatalf("Assets = %+v", latest.Assets)
+	}
+
+	rc, err := source.OpenAsset(ctx, latest.Assets[0])
+	if err != nil {
+		t.Fatalf("OpenAsset() error = %v", err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(data) != "notes" {
+		t.Errorf("OpenAsset() = %q, %v", data, err)
+	}
+
+	rc, err = source.OpenArchive(ctx, latest)
+	if err != nil {
+		t.Fatalf("OpenArchive() error = %v", err)
+	}
+	data, err = io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatalf("read archive: %v", err)
+	}
+	entries, err := (&Updater{}).readArchive(latest, data)
+	if err != nil {
+		t.Fatalf("readArchive() error = %v", err)
+	}
+	got := make(map[string]string)
+	for _, entry := range entries {
+		content, _ := entry.read()
+		got[entry.path] = string(content)
+	}
+	if want := map[string]string{"agents/a.md": "a2", "agents/b.md": "b2", "NOTES.md": "notes"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("archive = %v, want %v", got, want)
+	}
+}
+
+func TestOCISource_errors(t *testing.T) {
+	registry := newFakeRegistry(t)
+	layer := createTestTarGz(t, map[string]string{"agents/a.md": "a1"})
+	registry.push("v1.0.0", nil, fakeLayer{mediaType: "application/vnd.oci.image.layer.v1.tar+gzip", data: layer})
+	registry.corruptBlob(layer)
+
+	tests := []struct {
+		name    string
+		source  *OCISource
+		archive bool
+		wantErr string
+	}{
+		{name: "empty registry", source: &OCISource{Repository: "team/pack"}, wantErr: "registry url cannot be empty"},
+		{name: "empty repository", source: &OCISource{BaseURL: registry.URL}, wantErr: "repository cannot be empty"},
+		{name: "bad credentials", source: &OCISource{BaseURL: registry.URL, Repository: "team/pack", Username: "robot", Password: "nope"}, wantErr: "authorize"},
+		{name: "unknown repository", source: &OCISource{BaseURL: registry.URL, Repository: "team/other", Token: fakeRegistryToken}, wantErr: "HTTP 404"},
+		{name: "digest mismatch", source: newTestOCISource(registry), archive: true, wantErr: "digest mismatch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.archive {
+				_, err = tt.source.OpenArchive(context.Background(), &Release{Tag: "v1.0.0"})
+			} else {
+				_, err = tt.source.LatestRelease(context.Background())
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	tests := []struct {
+		challenge  string
+		wantScheme string
+		wantParams map[string]string
+	}{
+		{
+			challenge:  `Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:team/pack:pull,push"`,
+			wantScheme: "Bearer",
+			wantParams: map[string]string{"realm": "https://auth.example.com/token", "service": "registry.example.com", "scope": "repository:team/pack:pull,push"},
+		},
+		{challenge: `Basic realm="registry"`, wantScheme: "Basic", wantParams: map[string]string{"realm": "registry"}},
+		{challenge: `Bearer realm=https://auth.example.com/token, service=registry`, wantScheme: "Bearer", wantParams: map[string]string{"realm": "https://auth.example.com/token", "service": "registry"}},
+		{challenge: "", wantScheme: "", wantParams: map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.challenge, func(t *testing.T) {
+			scheme, params := parseChallenge(tt.challenge)
+			if scheme != tt.wantScheme || !reflect.DeepEqual(params, tt.wantParams) {
+				t.Errorf("parseChallenge() = %q, %v, want %q, %v", scheme, params, tt.wantScheme, tt.wantParams)
+			}
+		})
+	}
+}
+
+func TestUpdater_ociSource(t *testing.T) {
+	registry := newFakeRegistry(t)
+	registry.push("v1.0.0", nil, fakeLayer{
+		mediaType: "application/vnd.oci.image.layer.v1.tar",
+		data:      createTestTar(t, map[string]string{"agents/a.md": "a1", "agents/b.md": "b1"}),
+	})
+
+	tmpDir := t.TempDir()
+	agentsDir := filepath.Join(tmpDir, "agents")
+	updater := mustNewUpdater(t, UpdaterConfig{
+		Source:       newTestOCISource(registry),
+		MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+		Targets:      []ExtractTarget{{PathTransformer: &SubDirTransformer{SubDir: "agents"}, DestDir: agentsDir}},
+	})
+
+	result, err := updater.UpdateWithResult(context.Background())
+	if err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	if result.Version != "v1.0.0" || result.Targets[0].Written != 2 {
+		t.Errorf("UpdateWithResult() = %+v", result)
+	}
+	for name, want := range map[string]string{"a.md": "a1", "b.md": "b1"} {
+		content, err := os.ReadFile(filepath.Join(agentsDir, name))
+		if err != nil || string(content) != want {
+			t.Errorf("%s = %q, %v, want %q", name, content, err, want)
+		}
+	}
+}
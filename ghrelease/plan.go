@@ -0,0 +1,405 @@
+package ghrelease
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+var ErrPlanStale = errors.New("plan is stale")
+
+func (u *Updater) Plan(ctx context.Context) (*Plan, error) {
+	release, err := u.latestRelease(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get latest release: %w", err)
+	}
+
+	data, warnings, err := u.fetchArchive(ctx, release)
+	if err != nil {
+		return nil, fmt.Errorf("download release: %w", err)
+	}
+
+	metadata := u.loadMetadata()
+	plan, err := u.buildPlan(release, data, metadata)
+	if err != nil {
+		return nil, fmt.Errorf("build plan: %w", err)
+	}
+	plan.Reason = u.updateReason(metadata, release.Tag)
+	plan.warnings = warnings
+
+	return plan, nil
+}
+
+func (u *Updater) Apply(ctx context.Context, plan *Plan) (*UpdateResult, error) {
+	if plan.updater != u {
+		return nil, fmt.Errorf("plan was not created by this updater")
+	}
+
+	result, err := u.apply(ctx, plan)
+	if err != nil {
+		return nil, err
+	}
+	u.notify(result)
+	return result, nil
+}
+
+func (u *Updater) apply(ctx context.Context, plan *Plan) (*UpdateResult, error) {
+	u.runMu.Lock()
+	defer u.runMu.Unlock()
+
+	lock, err := u.acquireLock(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire lock: %w", err)
+	}
+	defer lock.unlock()
+
+	if err := u.checkPlan(plan); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	result := u.newUpdateResult()
+	result.PreviousVersion = plan.PreviousVersion
+	result.Version = plan.Version
+	result.Reason = plan.Reason
+
+	if err := u.applyPlan(ctx, plan, result); err != nil {
+		return nil, err
+	}
+	result.Durations.Total = time.Since(start)
+
+	return result, nil
+}
+
+func (u *Updater) buildPlan(release *Release, data []byte, metadata Metadata) (*Plan, error) {
+	entries, err := u.readArchive(release, data)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := u.newPlan(metadata.Files)
+	plan.PreviousVersion = metadata.Version
+	plan.Version = release.Tag
+	plan.release = release
+	plan.entries = entries
+
+	if err := u.planInstalls(plan, entries); err != nil {
+		return nil, err
+	}
+	if err := u.planDeletes(plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (u *Updater) newPlan(previous map[string]string) *Plan {
+	return &Plan{
+		updater:  u,
+		previous: previous,
+		files:    make(map[string]string),
+		contents: make(map[string][]byte),
+	}
+}
+
+func (p *Plan) metadata() Metadata {
+	m := Metadata{Version: p.Version, Files: p.files}
+	if p.release != nil && p.release.Commit != "" {
+		m.Commit = p.release.Commit
+		m.Ref = p.release.Ref
+		if !p.release.PublishedAt.IsZero() {
+			m.CommitDate = p.release.PublishedAt.Format(time.RFC3339)
+		}
+	}
+	return m
+}
+
+func (u *Updater) planInstalls(plan *Plan, entries []archiveEntry) error {
+	planned := make(map[string]int)
+
+	return u.walkTargetFiles(entries, func(index int, path string, content []byte) error {
+		op, err := u.planInstall(index, path, content, plan.previous[path])
+		if err != nil {
+			return err
+		}
+
+		plan.files[path] = op.Hash
+		plan.contents[op.Hash] = content
+		if idx, ok := planned[path]; ok {
+			plan.Operations[idx] = op
+			return nil
+		}
+		planned[path] = len(plan.Operations)
+		plan.Operations = append(plan.Operations, op)
+		return nil
+	})
+}
+
+func (u *Updater) walkTargetFiles(entries []archiveEntry, fn func(index int, path string, content []byte) error) error {
+	for _, entry := range entries {
+		for i, target := range u.config.Targets {
+			destPath := target.PathTransformer.Transform(entry.path)
+			if destPath == "" {
+				continue
+			}
+
+			fullPath := filepath.Clean(filepath.Join(target.DestDir, destPath))
+			if !filepath.IsAbs(fullPath) {
+				return fmt.Errorf("destination path must be absolute: %s", fullPath)
+			}
+			if !isWithinDir(fullPath, filepath.Clean(target.DestDir)) {
+				return fmt.Errorf("archive entry %s escapes %s", entry.path, target.DestDir)
+			}
+
+			content, err := entry.read()
+			if err != nil {
+				return err
+			}
+
+			if err := fn(i, fullPath, content); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (u *Updater) planInstall(index int, path string, content []byte, previousHash string) (PlanOperation, error) {
+	target := u.config.Targets[index]
+	op := PlanOperation{
+		Path:    path,
+		DestDir: target.DestDir,
+		Target:  index,
+		Size:    int64(len(content)),
+		Hash:    hashBytes(content),
+	}
+
+	localHash, err := hashFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return op, err
+	}
+	op.LocalHash = localHash
+
+	switch {
+	case localHash == op.Hash:
+		op.Action = PlanUnchanged
+	case localHash == "":
+		op.Action = PlanCreate
+	default:
+		op.Action = PlanOverwrite
+		if policy := target.conflictPolicy(); isLocallyModified(policy, localHash, previousHash) {
+			op.Conflict = policy
+		}
+	}
+	return op, nil
+}
+
+func (u *Updater) planDeletes(plan *Plan) error {
+	keep := make(map[string]bool, len(plan.files))
+	for path := range plan.files {
+		keep[path] = true
+	}
+
+	paths := make([]string, 0, len(plan.previous))
+	for path := range plan.previous {
+		if _, ok := plan.files[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		i, ok := u.targetFor(path)
+		if !ok {
+			continue
+		}
+		target := u.config.Targets[i]
+
+		localHash, err := hashFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		op := PlanOperation{Action: PlanDelete, Path: path, DestDir: target.DestDir, Target: i, LocalHash: localHash}
+		if policy := target.removalPolicy(); isLocallyModified(policy, localHash, plan.previous[path]) {
+			op.Conflict = policy
+		}
+		keep[path] = true
+		plan.Operations = append(plan.Operations, op)
+	}
+
+	for i, target := range u.config.Targets {
+		if !target.Mirror {
+			continue
+		}
+		if err := u.planMirror(plan, i, keep); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (u *Updater) planMirror(plan *Plan, index int, keep map[string]bool) error {
+	destDir := filepath.Clean(u.config.Targets[index].DestDir)
+	metadataFile := filepath.Clean(u.config.MetadataFile)
+	lockFile := filepath.Clean(u.lockFile())
+
+	return filepath.WalkDir(destDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			if u.config.PristineDir != "" && path == filepath.Clean(u.config.PristineDir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if keep[path] || path == metadataFile || path == lockFile || isConflictCopy(path, keep) {
+			return nil
+		}
+
+		localHash, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		keep[path] = true
+		plan.Operations = append(plan.Operations, PlanOperation{
+			Action:    PlanDelete,
+			Path:      path,
+			DestDir:   destDir,
+			Target:    index,
+			LocalHash: localHash,
+			Mirror:    true,
+		})
+		return nil
+	})
+}
+
+func (u *Updater) checkPlan(plan *Plan) error {
+	if version := u.getLocalVersion(); version != plan.PreviousVersion {
+		return fmt.Errorf("%w: local version is %q, plan expects %q", ErrPlanStale, version, plan.PreviousVersion)
+	}
+
+	for _, op := range plan.Operations {
+		localHash, err := hashFile(op.Path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if localHash != op.LocalHash {
+			return fmt.Errorf("%w: %s changed since planning", ErrPlanStale, op.Path)
+		}
+	}
+	return nil
+}
+
+func (u *Updater) applyPlan(ctx context.Context, plan *Plan, result *UpdateResult) error {
+	result.Warnings = append(result.Warnings, plan.warnings...)
+
+	var event *HookEvent
+	if u.hasHooks() {
+		phaseStart := time.Now()
+		var err error
+		event, err = u.stage(plan)
+		if err != nil {
+			return fmt.Errorf("stage release: %w", err)
+		}
+		defer os.RemoveAll(event.StagingDir)
+
+		if err := u.runPreInstall(ctx, *event); err != nil {
+			return fmt.Errorf("pre-install hook: %w", err)
+		}
+		result.Durations.Hooks = time.Since(phaseStart)
+	}
+
+	phaseStart := time.Now()
+	if err := u.applyInstalls(plan, result); err != nil {
+		return fmt.Errorf("extract release: %w", err)
+	}
+	result.Durations.Extract = time.Since(phaseStart)
+
+	phaseStart = time.Now()
+	if err := u.applyDeletes(plan, result); err != nil {
+		return fmt.Errorf("prune files: %w", err)
+	}
+	result.Durations.Prune = time.Since(phaseStart)
+
+	if err := u.saveMetadata(plan.metadata()); err != nil {
+		result.addWarning("save metadata: %v", err)
+	} else if err := u.prunePristine(plan.files); err != nil {
+		result.addWarning("prune pristine store: %v", err)
+	}
+
+	if event != nil {
+		phaseStart = time.Now()
+		u.runPostInstall(ctx, *event, result)
+		result.Durations.Hooks += time.Since(phaseStart)
+	}
+	return nil
+}
+
+func (u *Updater) applyInstalls(plan *Plan, result *UpdateResult) error {
+	for _, op := range plan.Operations {
+		if op.Action == PlanDelete {
+			continue
+		}
+
+		target := u.config.Targets[op.Target]
+		written, err := u.installFile(target, op.Path, plan.contents[op.Hash], plan.previous[op.Path], result)
+		if err != nil {
+			return err
+		}
+		if written {
+			result.Targets[op.Target].Written++
+			result.Targets[op.Target].WrittenFiles = append(result.Targets[op.Target].WrittenFiles, op.Path)
+		} else {
+			result.Targets[op.Target].Skipped++
+		}
+	}
+	return nil
+}
+
+func (u *Updater) applyDeletes(plan *Plan, result *UpdateResult) error {
+	for _, op := range plan.Operations {
+		if op.Action != PlanDelete {
+			continue
+		}
+
+		target := u.config.Targets[op.Target]
+		if op.Mirror {
+			if err := os.Remove(op.Path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			result.addRemoved(op.Target, op.Path)
+		} else {
+			removed, conflict, err := u.removeOwnedFile(target, op.Path, plan.previous[op.Path])
+			if err != nil {
+				return err
+			}
+			if removed {
+				result.addRemoved(op.Target, op.Path)
+			}
+			if conflict != nil {
+				result.Conflicts = append(result.Conflicts, *conflict)
+			}
+		}
+		removeEmptyParents(filepath.Dir(op.Path), filepath.Clean(target.DestDir))
+	}
+
+	for _, target := range u.config.Targets {
+		if target.Mirror {
+			removeEmptyDirs(filepath.Clean(target.DestDir))
+		}
+	}
+	return nil
+}
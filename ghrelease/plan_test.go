@@ -0,0 +1,270 @@
+package ghrelease
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func newPlanTestUpdater(t *testing.T, latest *string, policy ConflictPolicy, mirror bool) (*Updater, string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	destDir := filepath.Join(tmpDir, "agents")
+
+	server := newFakeGitHub(t, latest, map[string]fakeRelease{
+		"v1.0.0": {tag: "v1.0.0", files: map[string]string{
+			"agents/same.md":    "same",
+			"agents/changed.md": "v1",
+			"agents/edited.md":  "v1",
+			"agents/old.md":     "old",
+		}},
+		"v1.1.0": {tag: "v1.1.0", files: map[string]string{
+			"agents/same.md":    "same",
+			"agents/changed.md": "v2",
+			"agents/edited.md":  "v2",
+			"agents/new.md":     "new",
+		}},
+	})
+
+	updater := mustNewUpdater(t, UpdaterConfig{
+		MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+		Targets: []ExtractTarget{{
+			PathTransformer: &SubDirTransformer{SubDir: "agents"},
+			DestDir:         destDir,
+			ConflictPolicy:  policy,
+			Mirror:          mirror,
+		}},
+	})
+	useFakeGitHub(t, updater, server)
+	return updater, destDir
+}
+
+func planActions(plan *Plan) map[string]PlanOperation {
+	ops := make(map[string]PlanOperation)
+	for _, op := range plan.Operations {
+		ops[filepath.Base(op.Path)] = op
+	}
+	return ops
+}
+
+func TestUpdater_Plan(t *testing.T) {
+	latest := "v1.0.0"
+	updater, destDir := newPlanTestUpdater(t, &latest, ConflictKeepLocal, true)
+
+	plan, err := updater.Plan(context.Background())
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if counts := plan.Counts(); counts[PlanCreate] != 4 || len(plan.Operations) != 4 {
+		t.Errorf("initial plan counts = %v, want 4 creates", counts)
+	}
+	if _, err := os.Stat(destDir); !os.IsNotExist(err) {
+		t.Error("Plan() should not write any files")
+	}
+
+	if _, err := updater.Apply(context.Background(), plan); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	writeTestFiles(t, map[string]string{
+		filepath.Join(destDir, "edited.md"): "local edit",
+		filepath.Join(destDir, "stray.md"):  "stray",
+	})
+
+	latest = "v1.1.0"
+	plan, err = updater.Plan(context.Background())
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if plan.PreviousVersion != "v1.0.0" || plan.Version != "v1.1.0" || plan.Reason != UpdateReasonVersionChanged {
+		t.Errorf("plan versions = %q -> %q (%s)", plan.PreviousVersion, plan.Version, plan.Reason)
+	}
+
+	ops := planActions(plan)
+	tests := []struct {
+		file     string
+		action   PlanAction
+		conflict ConflictPolicy
+		mirror   bool
+	}{
+		{file: "same.md", action: PlanUnchanged},
+		{file: "changed.md", action: PlanOverwrite},
+		{file: "edited.md", action: PlanOverwrite, conflict: ConflictKeepLocal},
+		{file: "new.md", action: PlanCreate},
+		{file: "old.md", action: PlanDelete},
+		{file: "stray.md", action: PlanDelete, mirror: true},
+	}
+	for _, tt := range tests {
+		op, ok := ops[tt.file]
+		if !ok {
+			t.Errorf("no operation for %s", tt.file)
+			continue
+		}
+		if op.Action != tt.action || op.Conflict != tt.conflict || op.Mirror != tt.mirror {
+			t.Errorf("%s: op = %+v, want action %s conflict %q mirror %v", tt.file, op, tt.action, tt.conflict, tt.mirror)
+		}
+	}
+	if op := ops["new.md"]; op.Size != 3 || op.Hash != hashBytes([]byte("new")) {
+		t.Errorf("new.md size/hash = %d/%s", op.Size, op.Hash)
+	}
+
+	result, err := updater.Apply(context.Background(), plan)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got := result.Targets[0]; got.Written != 2 || got.Skipped != 2 || got.Removed != 2 {
+		t.Errorf("target result = %+v, want 2 written, 2 skipped, 2 removed", got)
+	}
+
+	wantFiles := map[string]string{
+		"same.md":    "same",
+		"changed.md": "v2",
+		"edited.md":  "local edit",
+		"new.md":     "new",
+	}
+	for name, want := range wantFiles {
+		content, err := os.ReadFile(filepath.Join(destDir, name))
+		if err != nil || string(content) != want {
+			t.Errorf("%s = %q (%v), want %q", name, string(content), err, want)
+		}
+	}
+	for _, name := range []string{"old.md", "stray.md"} {
+		if _, err := os.Stat(filepath.Join(destDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s should have been removed", name)
+		}
+	}
+}
+
+func TestUpdater_Apply_stalePlan(t *testing.T) {
+	latest := "v1.0.0"
+	updater, destDir := newPlanTestUpdater(t, &latest, "", false)
+
+	plan, err := updater.Plan(context.Background())
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	writeTestFiles(t, map[string]string{filepath.Join(destDir, "new.md"): "racing write", filepath.Join(destDir, "same.md"): "racing write"})
+
+	if _, err := updater.Apply(context.Background(), plan); !errors.Is(err, ErrPlanStale) {
+		t.Errorf("Apply() error = %v, want ErrPlanStale", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(destDir, "same.md"))
+	if string(content) != "racing write" {
+		t.Error("stale plan should not modify files")
+	}
+}
+
+func TestUpdater_Apply_foreignPlan(t *testing.T) {
+	latest := "v1.0.0"
+	updater, _ := newPlanTestUpdater(t, &latest, "", false)
+	other, _ := newPlanTestUpdater(t, &latest, "", false)
+
+	plan, err := updater.Plan(context.Background())
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if _, err := other.Apply(context.Background(), plan); err == nil {
+		t.Error("Apply() should reject a plan from another updater")
+	}
+}
+
+func TestPlan_render(t *testing.T) {
+	plan := &Plan{
+		PreviousVersion: "v1.0.0",
+		Version:         "v1.1.0",
+		Reason:          UpdateReasonVersionChanged,
+		Operations: []PlanOperation{
+			{Action: PlanCreate, Path: "/dest/new.md", Size: 3, Hash: "h1"},
+			{Action: PlanOverwrite, Path: "/dest/edited.md", Size: 5, Hash: "h2", Conflict: ConflictKeepLocal},
+			{Action: PlanDelete, Path: "/dest/stray.md", Mirror: true},
+			{Action: PlanUnchanged, Path: "/dest/same.md", Size: 4, Hash: "h3"},
+		},
+	}
+
+	want := strings.Join([]string{
+		"v1.0.0 -> v1.1.0 (version-changed)",
+		"  + /dest/new.md (3 bytes)",
+		"  ~ /dest/edited.md (5 bytes) [conflict: keep-local]",
+		"  - /dest/stray.md (mirror)",
+		"1 to create, 1 to overwrite, 1 to delete, 1 unchanged",
+		"",
+	}, "\n")
+	if got := plan.String(); got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+
+	data, err := plan.JSON()
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	var decoded struct {
+		Version    string             `json:"version"`
+		Operations []PlanOperation    `json:"operations"`
+		Summary    map[PlanAction]int `json:"summary"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal plan JSON: %v", err)
+	}
+	if decoded.Version != "v1.1.0" || len(decoded.Operations) != 4 || decoded.Summary[PlanDelete] != 1 {
+		t.Errorf("decoded plan = %+v", decoded)
+	}
+	if !plan.HasChanges() {
+		t.Error("HasChanges() = false, want true")
+	}
+}
+
+func TestUpdater_Apply_reusesPlannedArchive(t *testing.T) {
+	latest := "v1.0.0"
+	updater, _ := newPlanTestUpdater(t, &latest, "", false)
+
+	var downloads atomic.Int32
+	server := newFakeGitHub(t, &latest, map[string]fakeRelease{
+		"v1.0.0": {tag: "v1.0.0", files: map[string]string{"agents/a.md": "a"}},
+	})
+	useFakeGitHub(t, updater, server)
+	server.Config.Handler = countingHandler(server.Config.Handler, "/zipball/", &downloads)
+
+	plan, err := updater.Plan(context.Background())
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	result, err := updater.Apply(context.Background(), plan)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got := downloads.Load(); got != 1 {
+		t.Errorf("downloads = %d, want 1", got)
+	}
+	if result.Downloaded {
+		t.Error("Apply() Downloaded = true, want false for a planned archive")
+	}
+}
+
+func TestUpdater_Plan_pathTraversal(t *testing.T) {
+	tmpDir := t.TempDir()
+	destDir := filepath.Join(tmpDir, "content", "dest")
+	source := &fakeSource{t: t, latest: "v1", releases: map[string]map[string]string{
+		"v1": {"a.md": "a", "../../escaped.md": "escaped"},
+	}}
+	updater := mustNewUpdater(t, UpdaterConfig{
+		Source:       source,
+		MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+		Targets:      []ExtractTarget{{PathTransformer: &KeepAllTransformer{}, DestDir: destDir}},
+	})
+
+	_, err := updater.UpdateWithResult(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "escapes") {
+		t.Fatalf("UpdateWithResult() error = %v, want path escape error", err)
+	}
+	for _, path := range []string{filepath.Join(tmpDir, "escaped.md"), filepath.Join(destDir, "a.md")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should not be written, stat error = %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package ghrelease
+
+import (
+	"os"
+	"path/filepath"
+)
+
+type pristineStore struct {
+	dir string
+}
+
+func (s *pristineStore) put(content []byte) error {
+	path := filepath.Join(s.dir, hashBytes(content))
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	return writeFile(path, content)
+}
+
+func (s *pristineStore) get(hash string) ([]byte, error) {
+	if hash == "" {
+		return nil, os.ErrNotExist
+	}
+	return os.ReadFile(filepath.Join(s.dir, hash))
+}
+
+func (s *pristineStore) prune(keep map[string]bool) error {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || keep[entry.Name()] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package ghrelease
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPristineStore(t *testing.T) {
+	store := &pristineStore{dir: filepath.Join(t.TempDir(), "pristine")}
+
+	if _, err := store.get(""); !os.IsNotExist(err) {
+		t.Errorf("get(\"\") error = %v, want not exist", err)
+	}
+
+	for _, content := range []string{"one", "two", "one"} {
+		if err := store.put([]byte(content)); err != nil {
+			t.Fatalf("put(%q) error = %v", content, err)
+		}
+	}
+
+	got, err := store.get(hashBytes([]byte("two")))
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	if string(got) != "two" {
+		t.Errorf("get() = %q, want %q", string(got), "two")
+	}
+
+	if err := store.prune(map[string]bool{hashBytes([]byte("one")): true}); err != nil {
+		t.Fatalf("prune() error = %v", err)
+	}
+	if _, err := store.get(hashBytes([]byte("one"))); err != nil {
+		t.Errorf("kept content missing: %v", err)
+	}
+	if _, err := store.get(hashBytes([]byte("two"))); !os.IsNotExist(err) {
+		t.Errorf("pruned content error = %v, want not exist", err)
+	}
+}
+
+func TestPristineStore_pruneMissingDir(t *testing.T) {
+	store := &pristineStore{dir: filepath.Join(t.TempDir(), "missing")}
+	if err := store.prune(nil); err != nil {
+		t.Errorf("prune() error = %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package ghrelease
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func isConflictCopy(path string, keep map[string]bool) bool {
+	for _, suffix := range []string{newFileSuffix, backupFileSuffix} {
+		if strings.HasSuffix(path, suffix) && keep[strings.TrimSuffix(path, suffix)] {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *Updater) targetFor(path string) (int, bool) {
+	best, bestLen := -1, -1
+	for i, target := range u.config.Targets {
+		dir := filepath.Clean(target.DestDir)
+		if isWithinDir(path, dir) && len(dir) > bestLen {
+			best, bestLen = i, len(dir)
+		}
+	}
+	return best, best >= 0
+}
+
+func isWithinDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func removeEmptyParents(dir, stopDir string) {
+	for isWithinDir(dir, stopDir) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func removeEmptyDirs(root string) {
+	var dirs []string
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() && path != root {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Remove(dirs[i])
+	}
+}
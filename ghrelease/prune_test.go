@@ -0,0 +1,143 @@
+package ghrelease
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir for %s: %v", path, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+}
+
+func TestUpdater_applyPlan_prune(t *testing.T) {
+	tmpDir := t.TempDir()
+	destDir := filepath.Join(tmpDir, "agents")
+
+	oldFile := filepath.Join(destDir, "old.md")
+	nestedOld := filepath.Join(destDir, "nested", "gone.md")
+	keptFile := filepath.Join(destDir, "kept.md")
+	userFile := filepath.Join(destDir, "user.md")
+
+	writeTestFiles(t, map[string]string{
+		oldFile:   "old",
+		nestedOld: "gone",
+		keptFile:  "kept",
+		userFile:  "user",
+	})
+
+	updater := mustNewUpdater(t, UpdaterConfig{
+		Targets: []ExtractTarget{{PathTransformer: &KeepAllTransformer{}, DestDir: destDir}},
+	})
+
+	previous := map[string]string{
+		oldFile:   hashBytes([]byte("old")),
+		nestedOld: hashBytes([]byte("gone")),
+		keptFile:  hashBytes([]byte("kept")),
+	}
+
+	archive := createTestZip(t, map[string]string{"repo/kept.md": "kept"})
+	if _, _, err := applyTestArchive(t, updater, archive, previous); err != nil {
+		t.Fatalf("applyPlan() error = %v", err)
+	}
+
+	for _, path := range []string{oldFile, nestedOld, filepath.Dir(nestedOld)} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should have been removed", path)
+		}
+	}
+	for _, path := range []string{keptFile, userFile} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s should still exist: %v", path, err)
+		}
+	}
+}
+
+func TestUpdater_applyPlan_prune_missingFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	updater := mustNewUpdater(t, UpdaterConfig{
+		Targets: []ExtractTarget{{PathTransformer: &KeepAllTransformer{}, DestDir: tmpDir}},
+	})
+
+	previous := map[string]string{filepath.Join(tmpDir, "missing.md"): "h1"}
+	if _, _, err := applyTestArchive(t, updater, createTestZip(t, nil), previous); err != nil {
+		t.Fatalf("applyPlan() error = %v", err)
+	}
+	if _, err := os.Stat(tmpDir); err != nil {
+		t.Errorf("dest dir should not be removed: %v", err)
+	}
+}
+
+func TestUpdater_applyPlan_prune_mirror(t *testing.T) {
+	tmpDir := t.TempDir()
+	mirrorDir := filepath.Join(tmpDir, "mirror")
+	plainDir := filepath.Join(tmpDir, "plain")
+	metadataFile := filepath.Join(mirrorDir, "metadata.json")
+
+	keptFile := filepath.Join(mirrorDir, "kept.md")
+	strayFile := filepath.Join(mirrorDir, "stray", "file.md")
+	plainStray := filepath.Join(plainDir, "stray.md")
+
+	writeTestFiles(t, map[string]string{
+		keptFile:     "kept",
+		strayFile:    "stray",
+		plainStray:   "stray",
+		metadataFile: "{}",
+	})
+
+	updater := mustNewUpdater(t, UpdaterConfig{
+		MetadataFile: metadataFile,
+		Targets: []ExtractTarget{
+			{PathTransformer: &KeepAllTransformer{}, DestDir: mirrorDir, Mirror: true},
+			{PathTransformer: &KeepAllTransformer{}, DestDir: plainDir},
+		},
+	})
+
+	archive := createTestZip(t, map[string]string{"repo/kept.md": "kept"})
+	if _, _, err := applyTestArchive(t, updater, archive, nil); err != nil {
+		t.Fatalf("applyPlan() error = %v", err)
+	}
+
+	for _, path := range []string{strayFile, filepath.Dir(strayFile)} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should have been removed", path)
+		}
+	}
+	for _, path := range []string{keptFile, plainStray, metadataFile} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s should still exist: %v", path, err)
+		}
+	}
+}
+
+func TestIsWithinDir(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		dir  string
+		want bool
+	}{
+		{name: "direct child", path: "/a/b", dir: "/a", want: true},
+		{name: "nested child", path: "/a/b/c", dir: "/a", want: true},
+		{name: "same dir", path: "/a", dir: "/a", want: false},
+		{name: "sibling", path: "/ab", dir: "/a", want: false},
+		{name: "parent", path: "/", dir: "/a", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isWithinDir(tt.path, tt.dir); got != tt.want {
+				t.Errorf("isWithinDir(%q, %q) = %v, want %v", tt.path, tt.dir, got, tt.want)
+			}
+		})
+	}
+}
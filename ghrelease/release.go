@@ -0,0 +1,79 @@
+package ghrelease
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v68/github"
+)
+
+func newRelease(r *github.RepositoryRelease) (*Release, error) {
+	if r.TagName == nil {
+		return nil, fmt.Errorf("release tag name is nil")
+	}
+
+	release := &Release{
+		Tag:        r.GetTagName(),
+		Name:       r.GetName(),
+		Body:       r.GetBody(),
+		HTMLURL:    r.GetHTMLURL(),
+		Prerelease: r.GetPrerelease(),
+		ArchiveURL: r.GetZipballURL(),
+	}
+	if r.PublishedAt != nil {
+		release.PublishedAt = r.PublishedAt.Time
+	}
+	for _, a := range r.Assets {
+		release.Assets = append(release.Assets, Asset{
+			ID:          a.GetID(),
+			Name:        a.GetName(),
+			Size:        int64(a.GetSize()),
+			ContentType: a.GetContentType(),
+			DownloadURL: a.GetBrowserDownloadURL(),
+		})
+	}
+	return release, nil
+}
+
+func (u *Updater) latestRelease(ctx context.Context) (*Release, error) {
+	u.mu.Lock()
+	if u.config.CacheTTL > 0 && u.cachedRelease != nil && time.Since(u.cachedAt) < u.config.CacheTTL {
+		release := u.cachedRelease
+		u.mu.Unlock()
+		return release, nil
+	}
+	u.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, u.config.RequestTimeout)
+	defer cancel()
+
+	release, err := u.source.LatestRelease(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	u.mu.Lock()
+	u.cachedRelease = release
+	u.cachedAt = time.Now()
+	u.mu.Unlock()
+	return release, nil
+}
+
+func (u *Updater) releaseByTag(ctx context.Context, tag string) (*Release, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.config.RequestTimeout)
+	defer cancel()
+
+	return u.source.ReleaseByTag(ctx, tag)
+}
+
+func (u *Updater) updateReason(metadata Metadata, version string) UpdateReason {
+	switch {
+	case version != metadata.Version:
+		return UpdateReasonVersionChanged
+	case u.needsRedownload():
+		return UpdateReasonRedownload
+	default:
+		return ""
+	}
+}
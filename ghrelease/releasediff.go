@@ -0,0 +1,138 @@
+package ghrelease
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+func (u *Updater) Diff(ctx context.Context, fromVersion, toVersion string) (*ReleaseDiff, error) {
+	if fromVersion == "" {
+		fromVersion = u.getLocalVersion()
+	}
+
+	var toRelease *Release
+	var err error
+	if toVersion == "" {
+		toRelease, err = u.latestRelease(ctx)
+	} else {
+		toRelease, err = u.releaseByTag(ctx, toVersion)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get release %q: %w", toVersion, err)
+	}
+
+	toFiles, err := u.releaseContents(ctx, toRelease)
+	if err != nil {
+		return nil, fmt.Errorf("read release %s: %w", toRelease.Tag, err)
+	}
+
+	fromFiles := map[string][]byte{}
+	if fromVersion != "" {
+		fromRelease, err := u.releaseByTag(ctx, fromVersion)
+		if err != nil {
+			return nil, fmt.Errorf("get release %q: %w", fromVersion, err)
+		}
+		fromFiles, err = u.releaseContents(ctx, fromRelease)
+		if err != nil {
+			return nil, fmt.Errorf("read release %s: %w", fromRelease.Tag, err)
+		}
+	}
+
+	return u.diffContents(fromVersion, toRelease.Tag, fromFiles, toFiles), nil
+}
+
+func (u *Updater) releaseContents(ctx context.Context, release *Release) (map[string][]byte, error) {
+	data, _, err := u.fetchArchive(ctx, release)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := u.readArchive(release, data)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make(map[string][]byte)
+	err = u.walkTargetFiles(entries, func(index int, path string, content []byte) error {
+		contents[path] = content
+		return nil
+	})
+	return contents, err
+}
+
+func (u *Updater) diffContents(fromVersion, toVersion string, from, to map[string][]byte) *ReleaseDiff {
+	diff := &ReleaseDiff{From: fromVersion, To: toVersion}
+
+	paths := make([]string, 0, len(from)+len(to))
+	for path := range from {
+		paths = append(paths, path)
+	}
+	for path := range to {
+		if _, ok := from[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		oldContent, inFrom := from[path]
+		newContent, inTo := to[path]
+
+		file := FileDiff{Path: path}
+		switch {
+		case !inFrom:
+			file.Status = FileAdded
+		case !inTo:
+			file.Status = FileRemoved
+		case hashBytes(oldContent) == hashBytes(newContent):
+			continue
+		default:
+			file.Status = FileModified
+		}
+
+		name := u.diffName(path)
+		fromName, toName := "a/"+name, "b/"+name
+		if !inFrom {
+			fromName = "/dev/null"
+		}
+		if !inTo {
+			toName = "/dev/null"
+		}
+
+		if isBinary(oldContent) || isBinary(newContent) {
+			file.Binary = true
+			file.Patch = fmt.Sprintf("Binary files %s and %s differ\n", fromName, toName)
+		} else {
+			file.Patch, file.Additions, file.Deletions = unifiedDiff(fromName, toName, oldContent, newContent)
+		}
+
+		diff.Stats.FilesChanged++
+		diff.Stats.Additions += file.Additions
+		diff.Stats.Deletions += file.Deletions
+		diff.Files = append(diff.Files, file)
+	}
+
+	return diff
+}
+
+func (u *Updater) diffName(path string) string {
+	if i, ok := u.targetFor(path); ok {
+		if rel, err := filepath.Rel(u.config.Targets[i].DestDir, path); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+func (d *ReleaseDiff) String() string {
+	var b strings.Builder
+	for _, file := range d.Files {
+		b.WriteString(file.Patch)
+	}
+	fmt.Fprintf(&b, "%d files changed, %d insertions(+), %d deletions(-)\n",
+		d.Stats.FilesChanged, d.Stats.Additions, d.Stats.Deletions)
+	return b.String()
+}
@@ -0,0 +1,118 @@
+package ghrelease
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdater_Diff(t *testing.T) {
+	tmpDir := t.TempDir()
+	destDir := filepath.Join(tmpDir, "agents")
+
+	latest := "v2.0.0"
+	server := newFakeGitHub(t, &latest, map[string]fakeRelease{
+		"v1.0.0": {tag: "v1.0.0", files: map[string]string{
+			"agents/same.md":    "same\n",
+			"agents/changed.md": "line1\nline2\n",
+			"agents/removed.md": "bye\n",
+			"agents/logo.bin":   "\x00old",
+			"docs/ignored.md":   "ignored\n",
+		}},
+		"v2.0.0": {tag: "v2.0.0", files: map[string]string{
+			"agents/same.md":    "same\n",
+			"agents/changed.md": "line1\nline two\n",
+			"agents/added.md":   "hello\n",
+			"agents/logo.bin":   "\x00new",
+			"docs/ignored.md":   "changed\n",
+		}},
+	})
+
+	updater := mustNewUpdater(t, UpdaterConfig{
+		MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+		Targets:      []ExtractTarget{{PathTransformer: &SubDirTransformer{SubDir: "agents"}, DestDir: destDir}},
+	})
+	useFakeGitHub(t, updater, server)
+
+	diff, err := updater.Diff(context.Background(), "v1.0.0", "")
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if diff.From != "v1.0.0" || diff.To != "v2.0.0" {
+		t.Errorf("Diff() versions = %s..%s", diff.From, diff.To)
+	}
+
+	want := map[string]FileStatus{
+		filepath.Join(destDir, "added.md"):   FileAdded,
+		filepath.Join(destDir, "changed.md"): FileModified,
+		filepath.Join(destDir, "removed.md"): FileRemoved,
+		filepath.Join(destDir, "logo.bin"):   FileModified,
+	}
+	if len(diff.Files) != len(want) {
+		t.Fatalf("Diff() files = %+v, want %d files", diff.Files, len(want))
+	}
+	for _, file := range diff.Files {
+		if want[file.Path] != file.Status {
+			t.Errorf("%s status = %s, want %s", file.Path, file.Status, want[file.Path])
+		}
+		if file.Binary != strings.HasSuffix(file.Path, ".bin") {
+			t.Errorf("%s binary = %v", file.Path, file.Binary)
+		}
+	}
+
+	if diff.Stats.FilesChanged != 4 || diff.Stats.Additions != 2 || diff.Stats.Deletions != 2 {
+		t.Errorf("Stats = %+v, want 4 files, +2 -2", diff.Stats)
+	}
+
+	text := diff.String()
+	for _, fragment := range []string{
+		"--- /dev/null\n+++ b/added.md\n",
+		"--- a/changed.md\n+++ b/changed.md\n",
+		"-line2\n+line two\n",
+		"Binary files a/logo.bin and b/logo.bin differ",
+		"4 files changed, 2 insertions(+), 2 deletions(-)",
+	} {
+		if !strings.Contains(text, fragment) {
+			t.Errorf("String() missing %q in\n%s", fragment, text)
+		}
+	}
+}
+
+func TestUpdater_Diff_fromInstalled(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	latest := "v1.0.0"
+	server := newFakeGitHub(t, &latest, map[string]fakeRelease{
+		"v1.0.0": {tag: "v1.0.0", files: map[string]string{"a.md": "a\n"}},
+	})
+
+	updater := mustNewUpdater(t, UpdaterConfig{
+		MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+		Targets:      []ExtractTarget{{PathTransformer: &KeepAllTransformer{}, DestDir: filepath.Join(tmpDir, "dest")}},
+	})
+	useFakeGitHub(t, updater, server)
+
+	diff, err := updater.Diff(context.Background(), "", "")
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if diff.From != "" || len(diff.Files) != 1 || diff.Files[0].Status != FileAdded {
+		t.Errorf("Diff() from nothing = %+v", diff)
+	}
+
+	if err := updater.Update(); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	diff, err = updater.Diff(context.Background(), "", "")
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if diff.From != "v1.0.0" || len(diff.Files) != 0 {
+		t.Errorf("Diff() from installed = %+v, want no changes", diff)
+	}
+
+	if _, err := updater.Diff(context.Background(), "v0.0.1", ""); err == nil {
+		t.Error("Diff() should fail for unknown version")
+	}
+}
@@ -0,0 +1,75 @@
+package ghrelease
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+var planActionSymbols = map[PlanAction]string{
+	PlanCreate:    "+",
+	PlanOverwrite: "~",
+	PlanDelete:    "-",
+	PlanUnchanged: "=",
+}
+
+func (p *Plan) Counts() map[PlanAction]int {
+	counts := make(map[PlanAction]int)
+	for _, op := range p.Operations {
+		counts[op.Action]++
+	}
+	return counts
+}
+
+func (p *Plan) HasChanges() bool {
+	for _, op := range p.Operations {
+		if op.Action != PlanUnchanged {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Plan) String() string {
+	var b strings.Builder
+
+	previous := p.PreviousVersion
+	if previous == "" {
+		previous = "(none)"
+	}
+	fmt.Fprintf(&b, "%s -> %s", previous, p.Version)
+	if p.Reason != "" {
+		fmt.Fprintf(&b, " (%s)", p.Reason)
+	}
+	b.WriteString("\n")
+
+	for _, op := range p.Operations {
+		if op.Action == PlanUnchanged {
+			continue
+		}
+		fmt.Fprintf(&b, "  %s %s", planActionSymbols[op.Action], op.Path)
+		if op.Action != PlanDelete {
+			fmt.Fprintf(&b, " (%d bytes)", op.Size)
+		}
+		if op.Mirror {
+			b.WriteString(" (mirror)")
+		}
+		if op.Conflict != "" {
+			fmt.Fprintf(&b, " [conflict: %s]", op.Conflict)
+		}
+		b.WriteString("\n")
+	}
+
+	counts := p.Counts()
+	fmt.Fprintf(&b, "%d to create, %d to overwrite, %d to delete, %d unchanged\n",
+		counts[PlanCreate], counts[PlanOverwrite], counts[PlanDelete], counts[PlanUnchanged])
+
+	return b.String()
+}
+
+func (p *Plan) JSON() ([]byte, error) {
+	return json.MarshalIndent(struct {
+		*Plan
+		Summary map[PlanAction]int `json:"summary"`
+	}{p, p.Counts()}, "", "  ")
+}
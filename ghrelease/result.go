@@ -0,0 +1,27 @@
+package ghrelease
+
+import (
+	"fmt"
+)
+
+func (r *UpdateResult) Changed() bool {
+	for _, target := range r.Targets {
+		if target.Written > 0 || target.Removed > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *UpdateResult) addWarning(format string, args ...any) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+func (r *UpdateResult) addRemoved(index int, path string) {
+	r.Targets[index].Removed++
+	r.Targets[index].RemovedFiles = append(r.Targets[index].RemovedFiles, path)
+}
+
+func (r *VerifyResult) OK() bool {
+	return len(r.Missing) == 0 && len(r.Modified) == 0
+}
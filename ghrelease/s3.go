@@ -0,0 +1,270 @@
+package ghrelease
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultS3Region = "us-east-1"
+
+type s3Object struct {
+	Key          string    `xml:"Key"`
+	LastModified time.Time `xml:"LastModified"`
+	Size         int64     `xml:"Size"`
+}
+
+type s3ListResult struct {
+	IsTruncated           bool       `xml:"IsTruncated"`
+	NextContinuationToken string     `xml:"NextContinuationToken"`
+	Contents              []s3Object `xml:"Contents"`
+	CommonPrefixes        []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+}
+
+func NewS3Source(endpoint, bucket string) *S3Source {
+	return &S3Source{Endpoint: endpoint, Bucket: bucket}
+}
+
+func (s *S3Source) ListReleases(ctx context.Context) ([]*Release, error) {
+	if s.Bucket == "" {
+		return nil, fmt.Errorf("bucket cannot be empty")
+	}
+
+	objects, prefixes, err := s.list(ctx, s.prefix(), "/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list releases: %w", err)
+	}
+
+	var releases []*Release
+	seen := make(map[string]bool)
+	add := func(release *Release) {
+		if release != nil && !seen[release.Tag] {
+			seen[release.Tag] = true
+			releases = append(releases, release)
+		}
+	}
+	for _, prefix := range prefixes {
+		add(s.newRelease(prefix, time.Time{}, true))
+	}
+	for _, object := range objects {
+		add(s.newRelease(object.Key, object.LastModified, false))
+	}
+
+	sort.SliceStable(releases, func(i, j int) bool {
+		return CompareVersions(releases[i].Tag, releases[j].Tag) > 0
+	})
+	return releases, nil
+}
+
+func (s *S3Source) LatestRelease(ctx context.Context) (*Release, error) {
+	releases, err := s.ListReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return latestStable(releases, s.location())
+}
+
+func (s *S3Source) ReleaseByTag(ctx context.Context, tag string) (*Release, error) {
+	releases, err := s.ListReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return findRelease(releases, tag, s.location())
+}
+
+func (s *S3Source) OpenArchive(ctx context.Context, release *Release) (io.ReadCloser, error) {
+	if release.ArchiveURL == "" {
+		return nil, fmt.Errorf("release %s has no archive url", release.Tag)
+	}
+	key, err := s.keyFromURL(release.ArchiveURL)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(key, "/") {
+		return s.open(ctx, release.ArchiveURL)
+	}
+
+	objects, _, err := s.list(ctx, key, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", key, err)
+	}
+	files := make(map[string][]byte, len(objects))
+	for _, object := range objects {
+		if strings.HasSuffix(object.Key, "/") {
+			continue
+		}
+		rc, err := s.open(ctx, s.objectURL(object.Key))
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[strings.TrimPrefix(object.Key, key)] = data
+	}
+
+	data, err := zipFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *S3Source) OpenAsset(ctx context.Context, asset Asset) (io.ReadCloser, error) {
+	if asset.DownloadURL == "" {
+		return nil, fmt.Errorf("asset %s has no download url", asset.Name)
+	}
+	return s.open(ctx, asset.DownloadURL)
+}
+
+func (s *S3Source) newRelease(key string, modified time.Time, isPrefix bool) *Release {
+	name := strings.TrimSuffix(strings.TrimPrefix(key, s.prefix()), "/")
+	base := name
+	if !isPrefix {
+		var ok bool
+		if base, ok = trimArchiveExt(name); !ok {
+			return nil
+		}
+	}
+	version := manifestVersionPattern.FindString(base)
+	if version == "" {
+		return nil
+	}
+
+	return &Release{
+		Tag:         version,
+		Name:        name,
+		PublishedAt: modified,
+		Prerelease:  isPrerelease(version),
+		ArchiveURL:  s.objectURL(key),
+		FlatArchive: isPrefix || s.FlatArchives,
+	}
+}
+
+func (s *S3Source) list(ctx context.Context, prefix, delimiter string) ([]s3Object, []string, error) {
+	var objects []s3Object
+	var prefixes []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if delimiter != "" {
+			query.Set("delimiter", delimiter)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		rc, err := s.open(ctx, s.bucketURL()+"?"+canonicalQuery(query))
+		if err != nil {
+			return nil, nil, err
+		}
+		var result s3ListResult
+		err = xml.NewDecoder(rc).Decode(&result)
+		rc.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse bucket listing: %w", err)
+		}
+
+		objects = append(objects, result.Contents...)
+		for _, p := range result.CommonPrefixes {
+			prefixes = append(prefixes, p.Prefix)
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, prefixes, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (s *S3Source) open(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if s.AccessKeyID != "" && sameHost(rawURL, s.endpoint()) {
+		s.credentials().sign(req, time.Now())
+	}
+
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", rawURL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var s3Err struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		}
+		if xml.NewDecoder(resp.Body).Decode(&s3Err) == nil && s3Err.Code != "" {
+			return nil, fmt.Errorf("HTTP %d %s: %s: %s", resp.StatusCode, s3Err.Code, s3Err.Message, rawURL)
+		}
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, rawURL)
+	}
+	return resp.Body, nil
+}
+
+func (s *S3Source) credentials() sigV4Credentials {
+	return sigV4Credentials{
+		accessKeyID:     s.AccessKeyID,
+		secretAccessKey: s.SecretAccessKey,
+		sessionToken:    s.SessionToken,
+		region:          s.region(),
+		service:         "s3",
+	}
+}
+
+func (s *S3Source) keyFromURL(rawURL string) (string, error) {
+	escaped, ok := strings.CutPrefix(rawURL, s.bucketURL()+"/")
+	if !ok {
+		return "", fmt.Errorf("url is outside bucket %s: %s", s.Bucket, rawURL)
+	}
+	return url.PathUnescape(escaped)
+}
+
+func (s *S3Source) objectURL(key string) string {
+	return s.bucketURL() + "/" + awsEscape(key, true)
+}
+
+func (s *S3Source) bucketURL() string {
+	return strings.TrimSuffix(s.endpoint(), "/") + "/" + awsEscape(s.Bucket, false)
+}
+
+func (s *S3Source) endpoint() string {
+	if s.Endpoint == "" {
+		return "https://s3." + s.region() + ".amazonaws.com"
+	}
+	return s.Endpoint
+}
+
+func (s *S3Source) region() string {
+	if s.Region == "" {
+		return defaultS3Region
+	}
+	return s.Region
+}
+
+func (s *S3Source) prefix() string {
+	if s.Prefix == "" || strings.HasSuffix(s.Prefix, "/") {
+		return s.Prefix
+	}
+	return s.Prefix + "/"
+}
+
+func (s *S3Source) location() string {
+	return "s3://" + s.Bucket + "/" + s.prefix()
+}
+
+func (s *S3Source) httpClient() *http.Client {
+	return httpClientOrDefault(s.HTTPClient)
+}
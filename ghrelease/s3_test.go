@@ -0,0 +1,312 @@
+package ghrelease
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	fakeS3AccessKey = "minio"
+	fakeS3SecretKey = "minio-secret"
+)
+
+type fakeS3 struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	lists   int
+}
+
+func newFakeS3(t *testing.T, objects map[string][]byte) *fakeS3 {
+	t.Helper()
+
+	s3 := &fakeS3{objects: objects}
+	s3.Server = httptest.NewServer(http.HandlerFunc(s3.serve))
+	t.Cleanup(s3.Close)
+	return s3
+}
+
+func (f *fakeS3) serve(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(r) {
+		w.WriteHeader(http.StatusForbidden)
+		xml.NewEncoder(w).Encode(struct {
+			XMLName xml.Name `xml:"Error"`
+			Code    string   `xml:"Code"`
+			Message string   `xml:"Message"`
+		}{Code: "SignatureDoesNotMatch", Message: "bad signature"})
+		return
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != "releases" {
+		http.NotFound(w, r)
+		return
+	}
+	if key != "" {
+		f.mu.Lock()
+		data, ok := f.objects[key]
+		f.mu.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+		return
+	}
+
+	f.mu.Lock()
+	f.lists++
+	var keys []string
+	for key := range f.objects {
+		keys = append(keys, key)
+	}
+	f.mu.Unlock()
+	sort.Strings(keys)
+
+	query := r.URL.Query()
+	prefix, delimiter := query.Get("prefix"), query.Get("delimiter")
+	type entry struct {
+		key      string
+		isPrefix bool
+	}
+	var entries []entry
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		if i := strings.Index(rest, delimiter); delimiter != "" && i >= 0 {
+			if p := prefix + rest[:i+1]; !seen[p] {
+				seen[p] = true
+				entries = append(entries, entry{key: p, isPrefix: true})
+			}
+			continue
+		}
+		entries = append(entries, entry{key: key})
+	}
+
+	const pageSize = 2
+	start, _ := strconv.Atoi(query.Get("continuation-token"))
+	end := min(start+pageSize, len(entries))
+
+	var result s3ListResult
+	for _, e := range entries[start:end] {
+		if e.isPrefix {
+			result.CommonPrefixes = append(result.CommonPrefixes, struct {
+				Prefix string `xml:"Prefix"`
+			}{e.key})
+		} else {
+			result.Contents = append(result.Contents, s3Object{Key: e.key, LastModified: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Size: int64(len(f.objects[e.key]))})
+		}
+	}
+	if end < len(entries) {
+		result.IsTruncated = true
+		result.NextContinuationToken = strconv.Itoa(end)
+	}
+	xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"ListBucketResult"`
+		s3ListResult
+	}{s3ListResult: result})
+}
+
+func (f *fakeS3) authorized(r *http.Request) bool {
+	date, err := time.Parse(sigV4DateFormat, r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return false
+	}
+
+	req, err := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("X-Amz-Content-Sha256", r.Header.Get("X-Amz-Content-Sha256"))
+	sigV4Credentials{
+		accessKeyID:     fakeS3AccessKey,
+		secretAccessKey: fakeS3SecretKey,
+		region:          "eu-central-1",
+		service:         "s3",
+	}.sign(req, date)
+	return req.Header.Get("Authorization") == r.Header.Get("Authorization")
+}
+
+func newTestS3Source(server *fakeS3) *S3Source {
+	source := NewS3Source(server.URL, "releases")
+	source.Region = "eu-central-1"
+	source.Prefix = "content pack"
+	source.AccessKeyID = fakeS3AccessKey
+	source.SecretAccessKey = fakeS3SecretKey
+	return source
+}
+
+func newTestS3Objects(t *testing.T) map[string][]byte {
+	return map[string][]byte{
+		"content pack/pack-v1.0.0.zip":        createTestZip(t, map[string]string{"pack/agents/a.md": "a1", "pack/agents/old.md": "old"}),
+		"content pack/v1.1.0/agents/a.md":     []byte("a2"),
+		"content pack/v1.1.0/agents/b.md":     []byte("b2"),
+		"content pack/pack-v1.2.0-rc1.tar.gz": createTestTarGz(t, map[string]string{"pack/agents/a.md": "a3"}),
+		"content pack/README.txt":             []byte("ignored"),
+		"content pack/tmp/scratch":            []byte("ignored"),
+		"other/pack-v9.0.0.zip":               createTestZip(t, map[string]string{"pack/agents/a.md": "a9"}),
+	}
+}
+
+func TestS3Source(t *testing.T) {
+	server := newFakeS3(t, newTestS3Objects(t))
+	source := newTestS3Source(server)
+	ctx := context.Background()
+
+	releases, err := source.ListReleases(ctx)
+	if err != nil {
+		t.Fatalf("ListReleases() error = %v", err)
+	}
+	var tags []string
+	for _, release := range releases {
+		tags = append(tags, release.Tag)
+	}
+	if want := []string{"v1.2.0-rc1", "v1.1.0", "v1.0.0"}; !reflect.DeepEqual(tags, want) {
+		t.Fatalf("ListReleases() tags = %v, want %v", tags, want)
+	}
+	server.mu.Lock()
+	lists := server.lists
+	server.mu.Unlock()
+	if lists < 2 {
+		t.Errorf("listing should be paginated, got %d requests", lists)
+	}
+
+	old := releases[2]
+	if old.ArchiveURL != server.URL+"/releases/content%20pack/pack-v1.0.0.zip" || old.PublishedAt.IsZero() || old.FlatArchive {
+		t.Errorf("v1.0.0 = %+v", old)
+	}
+
+	latest, err := source.LatestRelease(ctx)
+	if err != nil {
+		t.Fatalf("LatestRelease() error = %v", err)
+	}
+	if latest.Tag != "v1.1.0" || !latest.FlatArchive {
+		t.Errorf("LatestRelease() = %+v", latest)
+	}
+
+	rc, err := source.OpenArchive(ctx, latest)
+	if err != nil {
+		t.Fatalf("OpenArchive() error = %v", err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatalf("read archive: %v", err)
+	}
+	entries, err := (&Updater{}).readArchive(latest, data)
+	if err != nil {
+		t.Fatalf("readArchive() error = %v", err)
+	}
+	var paths []string
+	for _, entry := range entries {
+		paths = append(paths, entry.path)
+	}
+	if want := []string{"agents/a.md", "agents/b.md"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("archive paths = %v, want %v", paths, want)
+	}
+}
+
+func TestS3Source_errors(t *testing.T) {
+	server := newFakeS3(t, newTestS3Objects(t))
+
+	tests := []struct {
+		name    string
+		source  func() *S3Source
+		wantErr string
+	}{
+		{name: "empty bucket", source: func() *S3Source { return &S3Source{Endpoint: server.URL} }, wantErr: "bucket cannot be empty"},
+		{name: "wrong secret", source: func() *S3Source {
+			source := newTestS3Source(server)
+			source.SecretAccessKey = "nope"
+			return source
+		}, wantErr: "SignatureDoesNotMatch"},
+		{name: "wrong region", source: func() *S3Source {
+			source := newTestS3Source(server)
+			source.Region = "us-west-2"
+			return source
+		}, wantErr: "HTTP 403"},
+		{name: "unsigned", source: func() *S3Source {
+			source := newTestS3Source(server)
+			source.AccessKeyID = ""
+			return source
+		}, wantErr: "HTTP 403"},
+		{name: "unknown bucket", source: func() *S3Source {
+			source := newTestS3Source(server)
+			source.Bucket = "missing"
+			return source
+		}, wantErr: "HTTP 404"},
+		{name: "no releases", source: func() *S3Source {
+			source := newTestS3Source(server)
+			source.Prefix = "empty/"
+			return source
+		}, wantErr: "no releases found in s3://releases/empty/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.source().LatestRelease(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LatestRelease() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := newTestS3Source(server).OpenArchive(context.Background(), &Release{Tag: "v1.0.0", ArchiveURL: "https://elsewhere.example.com/pack.zip"}); err == nil {
+		t.Error("OpenArchive() outside the bucket should fail")
+	}
+}
+
+func TestUpdater_s3Source(t *testing.T) {
+	objects := newTestS3Objects(t)
+	delete(objects, "content pack/v1.1.0/agents/a.md")
+	delete(objects, "content pack/v1.1.0/agents/b.md")
+	server := newFakeS3(t, objects)
+
+	tmpDir := t.TempDir()
+	agentsDir := filepath.Join(tmpDir, "agents")
+	updater := mustNewUpdater(t, UpdaterConfig{
+		Source:       newTestS3Source(server),
+		MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+		Targets:      []ExtractTarget{{PathTransformer: &SubDirTransformer{SubDir: "agents"}, DestDir: agentsDir}},
+	})
+	ctx := context.Background()
+
+	if _, err := updater.UpdateWithResult(ctx); err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+
+	server.mu.Lock()
+	server.objects["content pack/v1.1.0/agents/a.md"] = []byte("a2")
+	server.objects["content pack/v1.1.0/agents/b.md"] = []byte("b2")
+	server.mu.Unlock()
+
+	result, err := updater.UpdateWithResult(ctx)
+	if err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	if result.PreviousVersion != "v1.0.0" || result.Version != "v1.1.0" || result.Targets[0].Removed != 1 {
+		t.Errorf("UpdateWithResult() = %+v", result)
+	}
+	for name, want := range map[string]string{"a.md": "a2", "b.md": "b2"} {
+		content, err := os.ReadFile(filepath.Join(agentsDir, name))
+		if err != nil || string(content) != want {
+			t.Errorf("%s = %q, %v, want %q", name, content, err, want)
+		}
+	}
+}
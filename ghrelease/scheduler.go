@@ -0,0 +1,124 @@
+package ghrelease
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+const (
+	defaultSchedulerInterval = time.Hour
+	defaultRetryDelay        = 30 * time.Second
+)
+
+func NewScheduler(updater *Updater, config SchedulerConfig) (*Scheduler, error) {
+	if updater == nil {
+		return nil, fmt.Errorf("updater cannot be nil")
+	}
+	if config.Interval < 0 || config.Jitter < 0 || config.RetryDelay < 0 || config.MaxBackoff < 0 {
+		return nil, fmt.Errorf("scheduler durations cannot be negative")
+	}
+	if config.Interval == 0 {
+		config.Interval = defaultSchedulerInterval
+	}
+	if config.RetryDelay == 0 {
+		config.RetryDelay = min(defaultRetryDelay, config.Interval)
+	}
+	if config.MaxBackoff == 0 {
+		config.MaxBackoff = config.Interval
+	}
+
+	return &Scheduler{
+		updater: updater,
+		config:  config,
+		trigger: make(chan struct{}, 1),
+		results: make(chan SchedulerEvent, 1),
+	}, nil
+}
+
+func (s *Scheduler) Results() <-chan SchedulerEvent {
+	return s.results
+}
+
+func (s *Scheduler) Trigger() {
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) Run(ctx context.Context) error {
+	if !s.running.CompareAndSwap(false, true) {
+		return fmt.Errorf("scheduler is already running")
+	}
+	defer s.running.Store(false)
+
+	failures := 0
+	triggered := false
+	for {
+		result, err := s.updater.UpdateWithResult(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			failures++
+		} else {
+			failures = 0
+		}
+
+		delay := s.nextDelay(failures)
+		s.publish(SchedulerEvent{
+			Result:    result,
+			Err:       err,
+			Triggered: triggered,
+			Failures:  failures,
+			Next:      delay,
+		})
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-s.trigger:
+			timer.Stop()
+			triggered = true
+		case <-timer.C:
+			triggered = false
+		}
+	}
+}
+
+func (s *Scheduler) nextDelay(failures int) time.Duration {
+	delay := s.config.Interval
+	if failures > 0 {
+		delay = s.config.RetryDelay
+		for i := 1; i < failures && delay < s.config.MaxBackoff; i++ {
+			delay *= 2
+		}
+		delay = min(delay, s.config.MaxBackoff)
+	}
+	if s.config.Jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(s.config.Jitter)))
+	}
+	return delay
+}
+
+func (s *Scheduler) publish(event SchedulerEvent) {
+	if s.config.OnResult != nil {
+		s.config.OnResult(event)
+	}
+
+	for {
+		select {
+		case s.results <- event:
+			return
+		default:
+		}
+		select {
+		case <-s.results:
+		default:
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package ghrelease
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newSchedulerUpdater(t *testing.T) *Updater {
+	t.Helper()
+	tmpDir := t.TempDir()
+	latest := "v1.0.0"
+	server := newFakeGitHub(t, &latest, map[string]fakeRelease{
+		"v1.0.0": {tag: "v1.0.0", files: map[string]string{"a.md": "a"}},
+	})
+
+	updater := mustNewUpdater(t, UpdaterConfig{
+		MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+		Targets:      []ExtractTarget{{PathTransformer: &KeepAllTransformer{}, DestDir: filepath.Join(tmpDir, "dest")}},
+	})
+	useFakeGitHub(t, updater, server)
+	return updater
+}
+
+func receiveEvent(t *testing.T, events <-chan SchedulerEvent) SchedulerEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for scheduler event")
+		return SchedulerEvent{}
+	}
+}
+
+func sendEvent(events chan<- SchedulerEvent) func(SchedulerEvent) {
+	return func(event SchedulerEvent) {
+		select {
+		case events <- event:
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func startScheduler(t *testing.T, scheduler *Scheduler) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- scheduler.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Errorf("Run() error = %v, want context.Canceled", err)
+		}
+	})
+}
+
+func TestNewScheduler(t *testing.T) {
+	updater := mustNewUpdater(t, UpdaterConfig{})
+
+	tests := []struct {
+		name    string
+		updater *Updater
+		config  SchedulerConfig
+		want    SchedulerConfig
+		wantErr bool
+	}{
+		{
+			name:    "defaults",
+			updater: updater,
+			want:    SchedulerConfig{Interval: time.Hour, RetryDelay: 30 * time.Second, MaxBackoff: time.Hour},
+		},
+		{
+			name:    "short interval caps retry delay",
+			updater: updater,
+			config:  SchedulerConfig{Interval: 10 * time.Second},
+			want:    SchedulerConfig{Interval: 10 * time.Second, RetryDelay: 10 * time.Second, MaxBackoff: 10 * time.Second},
+		},
+		{
+			name:    "custom",
+			updater: updater,
+			config:  SchedulerConfig{Interval: time.Minute, Jitter: time.Second, RetryDelay: time.Second, MaxBackoff: 5 * time.Minute},
+			want:    SchedulerConfig{Interval: time.Minute, Jitter: time.Second, RetryDelay: time.Second, MaxBackoff: 5 * time.Minute},
+		},
+		{name: "nil updater", wantErr: true},
+		{name: "negative interval", updater: updater, config: SchedulerConfig{Interval: -time.Second}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduler, err := NewScheduler(tt.updater, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewScheduler() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := scheduler.config
+			if got.Interval != tt.want.Interval || got.Jitter != tt.want.Jitter || got.RetryDelay != tt.want.RetryDelay || got.MaxBackoff != tt.want.MaxBackoff {
+				t.Errorf("config = %+v, want %+v", scheduler.config, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduler_nextDelay(t *testing.T) {
+	scheduler, err := NewScheduler(mustNewUpdater(t, UpdaterConfig{}), SchedulerConfig{
+		Interval:   time.Hour,
+		RetryDelay: time.Second,
+		MaxBackoff: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewScheduler() error = %v", err)
+	}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 0, want: time.Hour},
+		{failures: 1, want: time.Second},
+		{failures: 2, want: 2 * time.Second},
+		{failures: 3, want: 4 * time.Second},
+		{failures: 4, want: 8 * time.Second},
+		{failures: 5, want: 10 * time.Second},
+		{failures: 100, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := scheduler.nextDelay(tt.failures); got != tt.want {
+			t.Errorf("nextDelay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestScheduler_nextDelay_jitter(t *testing.T) {
+	scheduler, err := NewScheduler(mustNewUpdater(t, UpdaterConfig{}), SchedulerConfig{
+		Interval: time.Minute,
+		Jitter:   time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewScheduler() error = %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		if got := scheduler.nextDelay(0); got < time.Minute || got >= time.Minute+time.Second {
+			t.Fatalf("nextDelay(0) = %v, want within [1m, 1m1s)", got)
+		}
+	}
+}
+
+func TestScheduler_Run(t *testing.T) {
+	events := make(chan SchedulerEvent)
+	scheduler, err := NewScheduler(newSchedulerUpdater(t), SchedulerConfig{
+		Interval: 10 * time.Millisecond,
+		OnResult: sendEvent(events),
+	})
+	if err != nil {
+		t.Fatalf("NewScheduler() error = %v", err)
+	}
+	startScheduler(t, scheduler)
+
+	first := receiveEvent(t, events)
+	if first.Err != nil || first.Result == nil || !first.Result.Changed() {
+		t.Fatalf("first event = %+v, want successful update", first)
+	}
+
+	second := receiveEvent(t, events)
+	if second.Err != nil || second.Result.Changed() || second.Triggered {
+		t.Errorf("second event = %+v, want scheduled no-op", second)
+	}
+	if second.Next != 10*time.Millisecond {
+		t.Errorf("Next = %v, want interval", second.Next)
+	}
+}
+
+func TestScheduler_Trigger(t *testing.T) {
+	scheduler, err := NewScheduler(newSchedulerUpdater(t), SchedulerConfig{Interval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewScheduler() error = %v", err)
+	}
+	startScheduler(t, scheduler)
+
+	if event := receiveEvent(t, scheduler.Results()); event.Triggered {
+		t.Errorf("initial event Triggered = true")
+	}
+
+	scheduler.Trigger()
+	if event := receiveEvent(t, scheduler.Results()); !event.Triggered || event.Err != nil {
+		t.Errorf("event = %+v, want triggered update", event)
+	}
+}
+
+func TestScheduler_Run_backoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	updater := mustNewUpdater(t, UpdaterConfig{MetadataFile: filepath.Join(t.TempDir(), "metadata.json")})
+	useFakeGitHub(t, updater, server)
+
+	events := make(chan SchedulerEvent)
+	scheduler, err := NewScheduler(updater, SchedulerConfig{
+		Interval:   time.Hour,
+		RetryDelay: time.Millisecond,
+		MaxBackoff: 4 * time.Millisecond,
+		OnResult:   sendEvent(events),
+	})
+	if err != nil {
+		t.Fatalf("NewScheduler() error = %v", err)
+	}
+	startScheduler(t, scheduler)
+
+	for want := 1; want <= 4; want++ {
+		event := receiveEvent(t, events)
+		if event.Err == nil {
+			t.Fatalf("event %d Err = nil, want error", want)
+		}
+		if event.Failures != want {
+			t.Errorf("event Failures = %d, want %d", event.Failures, want)
+		}
+		if wantNext := min(time.Millisecond<<(want-1), 4*time.Millisecond); event.Next != wantNext {
+			t.Errorf("event Next = %v, want %v", event.Next, wantNext)
+		}
+	}
+}
+
+func TestScheduler_Run_alreadyRunning(t *testing.T) {
+	scheduler, err := NewScheduler(newSchedulerUpdater(t), SchedulerConfig{Interval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewScheduler() error = %v", err)
+	}
+	startScheduler(t, scheduler)
+	receiveEvent(t, scheduler.Results())
+
+	if err := scheduler.Run(context.Background()); err == nil {
+		t.Error("second Run() should return error")
+	}
+}
+
+func TestScheduler_publish_keepsLatest(t *testing.T) {
+	scheduler, err := NewScheduler(mustNewUpdater(t, UpdaterConfig{}), SchedulerConfig{})
+	if err != nil {
+		t.Fatalf("NewScheduler() error = %v", err)
+	}
+
+	scheduler.publish(SchedulerEvent{Failures: 1})
+	scheduler.publish(SchedulerEvent{Failures: 2})
+
+	if event := receiveEvent(t, scheduler.Results()); event.Failures != 2 {
+		t.Errorf("Failures = %d, want latest event", event.Failures)
+	}
+}
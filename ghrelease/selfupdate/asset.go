@@ -0,0 +1,77 @@
+package selfupdate
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/workpi-ai/go-utils/ghrelease"
+)
+
+type templateData struct {
+	Name          string
+	Version       string
+	VersionNumber string
+	OS            string
+	Arch          string
+	Exe           string
+	Asset         string
+}
+
+func (u *Updater) templateData(release *ghrelease.Release) templateData {
+	data := templateData{
+		Name:          u.config.BinaryName,
+		Version:       release.Tag,
+		VersionNumber: normalizeVersion(release.Tag),
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+	}
+	if runtime.GOOS == "windows" {
+		data.Exe = ".exe"
+	}
+	return data
+}
+
+func (u *Updater) selectAsset(release *ghrelease.Release) (*ghrelease.Asset, error) {
+	if len(u.assetTemplates) == 0 {
+		asset, _, err := u.config.Matcher.Match(extractableAssets(release.Assets))
+		if err != nil {
+			return nil, fmt.Errorf("release %s: %w", release.Tag, err)
+		}
+		return asset, nil
+	}
+
+	data := u.templateData(release)
+
+	var tried []string
+	for _, tmpl := range u.assetTemplates {
+		name, err := executeTemplate(tmpl, data)
+		if err != nil {
+			return nil, fmt.Errorf("asset template %q: %w", tmpl.Name(), err)
+		}
+		tried = append(tried, name)
+
+		for i, asset := range release.Assets {
+			if strings.EqualFold(asset.Name, name) {
+				return &release.Assets[i], nil
+			}
+		}
+	}
+
+	available := make([]string, len(release.Assets))
+	for i, asset := range release.Assets {
+		available[i] = asset.Name
+	}
+	return nil, fmt.Errorf("no asset for %s/%s in release %s: tried %s; available %s",
+		data.OS, data.Arch, release.Tag, strings.Join(tried, ", "), strings.Join(available, ", "))
+}
+
+func extractableAssets(assets []ghrelease.Asset) []ghrelease.Asset {
+	var extractable []ghrelease.Asset
+	for _, asset := range assets {
+		if unsupportedExtension(asset.Name) == "" {
+			extractable = append(extractable, asset)
+		}
+	}
+	return extractable
+}
@@ -0,0 +1,98 @@
+package selfupdate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/workpi-ai/go-utils/ghrelease"
+)
+
+var defaultChecksumTemplates = []string{
+	"{{.Name}}_{{.VersionNumber}}_checksums.txt",
+	"checksums.txt",
+	"SHA256SUMS",
+	"{{.Asset}}.sha256",
+}
+
+func (u *Updater) verify(ctx context.Context, release *ghrelease.Release, asset ghrelease.Asset, data []byte) (bool, error) {
+	if asset.SHA256 != "" {
+		return true, compareChecksum(asset.Name, data, asset.SHA256)
+	}
+
+	checksumAsset, err := u.checksumAsset(release, asset)
+	if err != nil {
+		return false, err
+	}
+	if checksumAsset == nil {
+		if u.config.AllowUnverified {
+			return false, nil
+		}
+		return false, fmt.Errorf("no checksum asset for %s in release %s", asset.Name, release.Tag)
+	}
+
+	sums, err := u.download(ctx, *checksumAsset)
+	if err != nil {
+		return false, fmt.Errorf("download %s: %w", checksumAsset.Name, err)
+	}
+
+	want, ok := findChecksum(sums, asset.Name)
+	if !ok {
+		return false, fmt.Errorf("%s has no checksum for %s", checksumAsset.Name, asset.Name)
+	}
+
+	if err := compareChecksum(asset.Name, data, want); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func compareChecksum(name string, data []byte, want string) error {
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, want) {
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", name, got, want)
+	}
+	return nil
+}
+
+func (u *Updater) checksumAsset(release *ghrelease.Release, asset ghrelease.Asset) (*ghrelease.Asset, error) {
+	data := u.templateData(release)
+	data.Asset = asset.Name
+
+	for _, tmpl := range u.checksumTemplates {
+		name, err := executeTemplate(tmpl, data)
+		if err != nil {
+			return nil, fmt.Errorf("checksum template %q: %w", tmpl.Name(), err)
+		}
+		for i, candidate := range release.Assets {
+			if strings.EqualFold(candidate.Name, name) {
+				return &release.Assets[i], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func findChecksum(sums []byte, name string) (string, bool) {
+	var lines [][]string
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 0 {
+			lines = append(lines, fields)
+		}
+	}
+
+	for _, fields := range lines {
+		if len(fields) >= 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return fields[0], true
+		}
+	}
+	if len(lines) == 1 && len(lines[0]) == 1 {
+		return lines[0][0], true
+	}
+	return "", false
+}
@@ -0,0 +1,30 @@
+package selfupdate
+
+import (
+	"testing"
+)
+
+func TestFindChecksum(t *testing.T) {
+	tests := []struct {
+		name   string
+		sums   string
+		asset  string
+		want   string
+		wantOK bool
+	}{
+		{name: "sha256sum format", sums: "aaa  tool_linux_amd64.tar.gz\nbbb  tool_darwin_arm64.tar.gz\n", asset: "tool_darwin_arm64.tar.gz", want: "bbb", wantOK: true},
+		{name: "binary marker", sums: "ccc *tool.zip\n", asset: "tool.zip", want: "ccc", wantOK: true},
+		{name: "single hash file", sums: "ddd\n", asset: "tool.zip", want: "ddd", wantOK: true},
+		{name: "missing entry", sums: "aaa  other.zip\n", asset: "tool.zip"},
+		{name: "empty", sums: "", asset: "tool.zip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := findChecksum([]byte(tt.sums), tt.asset)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("findChecksum() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
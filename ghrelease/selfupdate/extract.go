@@ -0,0 +1,99 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+var unsupportedExtensions = []string{".tar.xz", ".txz", ".tar.zst", ".tzst", ".xz", ".zst"}
+
+func extractBinary(assetName string, data []byte, binaryName string) ([]byte, error) {
+	name := strings.ToLower(assetName)
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		return extractTar(gz, binaryName)
+	case strings.HasSuffix(name, ".tar"):
+		return extractTar(bytes.NewReader(data), binaryName)
+	case strings.HasSuffix(name, ".zip"):
+		return extractZip(data, binaryName)
+	case strings.HasSuffix(name, ".tar.bz2"), strings.HasSuffix(name, ".tbz2"):
+		return extractTar(bzip2.NewReader(bytes.NewReader(data)), binaryName)
+	case strings.HasSuffix(name, ".gz"):
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		return io.ReadAll(gz)
+	case strings.HasSuffix(name, ".bz2"):
+		return io.ReadAll(bzip2.NewReader(bytes.NewReader(data)))
+	}
+
+	if ext := unsupportedExtension(name); ext != "" {
+		return nil, fmt.Errorf("unsupported archive format %s", ext)
+	}
+	return data, nil
+}
+
+func unsupportedExtension(name string) string {
+	name = strings.ToLower(name)
+	for _, ext := range unsupportedExtensions {
+		if strings.HasSuffix(name, ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+func extractTar(r io.Reader, binaryName string) ([]byte, error) {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("binary %s not found in archive", binaryName)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeReg && isBinaryName(header.Name, binaryName) {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+func extractZip(data []byte, binaryName string) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range zr.File {
+		if !file.Mode().IsRegular() || !isBinaryName(file.Name, binaryName) {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return nil, fmt.Errorf("binary %s not found in archive", binaryName)
+}
+
+func isBinaryName(entry, binaryName string) bool {
+	base := path.Base(entry)
+	return base == binaryName || base == binaryName+".exe"
+}
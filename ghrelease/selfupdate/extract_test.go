@@ -0,0 +1,105 @@
+package selfupdate
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+const (
+	tarBz2Tool = "QlpoOTFBWSZTWSI1atEAAHh7hMmAAEDAAG0AAARwJZ4AAACACCAAVDKanpDQ0yMT1PQSUymg0aeoDQW+5o7oQdTASIjEjPK81kkgYJDWZnSW7keSWiJzCJ7fDNBZhA22HUP++pJAyLuSKcKEgRGrVog="
+	bz2Tool    = "QlpoOTFBWSZTWa+L1KgAAAABABAhIAAhmBmEYXckU4UJCvi9SoA="
+)
+
+func decodeBase64(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatalf("failed to decode fixture: %v", err)
+	}
+	return data
+}
+
+func TestExtractBinary(t *testing.T) {
+	tests := []struct {
+		name      string
+		assetName string
+		data      func(t *testing.T) []byte
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "tar.gz nested",
+			assetName: "tool_linux_amd64.tar.gz",
+			data: func(t *testing.T) []byte {
+				return createTarGz(t, map[string]string{"dist/tool": "bin", "dist/LICENSE": "license"})
+			},
+			want: "bin",
+		},
+		{
+			name:      "tgz",
+			assetName: "tool.TGZ",
+			data:      func(t *testing.T) []byte { return createTarGz(t, map[string]string{"tool": "bin"}) },
+			want:      "bin",
+		},
+		{
+			name:      "zip with exe",
+			assetName: "tool_windows_amd64.zip",
+			data:      func(t *testing.T) []byte { return createZip(t, map[string]string{"tool.exe": "bin"}) },
+			want:      "bin",
+		},
+		{
+			name:      "raw",
+			assetName: "tool_linux_amd64",
+			data:      func(t *testing.T) []byte { return []byte("bin") },
+			want:      "bin",
+		},
+		{
+			name:      "tar.bz2",
+			assetName: "tool_linux_amd64.tar.bz2",
+			data:      func(t *testing.T) []byte { return decodeBase64(t, tarBz2Tool) },
+			want:      "bin",
+		},
+		{
+			name:      "bz2",
+			assetName: "tool_linux_amd64.bz2",
+			data:      func(t *testing.T) []byte { return decodeBase64(t, bz2Tool) },
+			want:      "bin",
+		},
+		{
+			name:      "unsupported tar.xz",
+			assetName: "tool-x86_64-unknown-linux-musl.tar.xz",
+			data:      func(t *testing.T) []byte { return []byte("xz data") },
+			wantErr:   true,
+		},
+		{
+			name:      "unsupported zst",
+			assetName: "tool_linux_amd64.zst",
+			data:      func(t *testing.T) []byte { return []byte("zstd data") },
+			wantErr:   true,
+		},
+		{
+			name:      "missing binary",
+			assetName: "tool.zip",
+			data:      func(t *testing.T) []byte { return createZip(t, map[string]string{"other": "bin"}) },
+			wantErr:   true,
+		},
+		{
+			name:      "corrupt archive",
+			assetName: "tool.tar.gz",
+			data:      func(t *testing.T) []byte { return []byte("not gzip") },
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractBinary(tt.assetName, tt.data(t), "tool")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("extractBinary() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("extractBinary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
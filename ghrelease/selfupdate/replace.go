@@ -0,0 +1,55 @@
+package selfupdate
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const oldExecutableSuffix = ".old"
+
+func replaceExecutable(path string, content []byte, validate func(string) error) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".new-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	if validate != nil {
+		if err := validate(tmpPath); err != nil {
+			return err
+		}
+	}
+
+	if runtime.GOOS != "windows" {
+		return os.Rename(tmpPath, path)
+	}
+
+	oldPath := path + oldExecutableSuffix
+	os.Remove(oldPath)
+	if err := os.Rename(path, oldPath); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Rename(oldPath, path)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+//go:build !unix
+
+package selfupdate
+
+import (
+	"os"
+	"os/exec"
+)
+
+func Restart(executable string) error {
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	os.Exit(0)
+	return nil
+}
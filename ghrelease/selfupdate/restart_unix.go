@@ -0,0 +1,12 @@
+//go:build unix
+
+package selfupdate
+
+import (
+	"os"
+	"syscall"
+)
+
+func Restart(executable string) error {
+	return syscall.Exec(executable, os.Args, os.Environ())
+}
@@ -0,0 +1,180 @@
+package selfupdate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/workpi-ai/go-utils/ghrelease"
+)
+
+const (
+	defaultRequestTimeout  = 3 * time.Second
+	defaultDownloadTimeout = 5 * time.Minute
+)
+
+func New(config Config) (*Updater, error) {
+	if config.Source == nil {
+		if config.RepoOwner == "" {
+			return nil, fmt.Errorf("repo owner cannot be empty")
+		}
+		if config.RepoName == "" {
+			return nil, fmt.Errorf("repo name cannot be empty")
+		}
+		config.Source = ghrelease.NewGitHubSource(config.RepoOwner, config.RepoName)
+	}
+	if config.Executable == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("locate executable: %w", err)
+		}
+		config.Executable = exe
+	}
+	if resolved, err := filepath.EvalSymlinks(config.Executable); err == nil {
+		config.Executable = resolved
+	}
+	if config.BinaryName == "" {
+		config.BinaryName = strings.TrimSuffix(filepath.Base(config.Executable), ".exe")
+	}
+	if config.Matcher == nil && len(config.AssetTemplates) == 0 {
+		config.Matcher = &ghrelease.AssetMatcher{Name: config.BinaryName}
+	}
+	if len(config.ChecksumTemplates) == 0 {
+		config.ChecksumTemplates = defaultChecksumTemplates
+	}
+	if config.RequestTimeout == 0 {
+		config.RequestTimeout = defaultRequestTimeout
+	}
+	if config.DownloadTimeout == 0 {
+		config.DownloadTimeout = defaultDownloadTimeout
+	}
+
+	assetTemplates, err := parseTemplates(config.AssetTemplates)
+	if err != nil {
+		return nil, fmt.Errorf("asset templates: %w", err)
+	}
+	checksumTemplates, err := parseTemplates(config.ChecksumTemplates)
+	if err != nil {
+		return nil, fmt.Errorf("checksum templates: %w", err)
+	}
+
+	return &Updater{
+		config:            config,
+		source:            config.Source,
+		assetTemplates:    assetTemplates,
+		checksumTemplates: checksumTemplates,
+	}, nil
+}
+
+func (u *Updater) Check(ctx context.Context) (*Result, error) {
+	result, _, err := u.check(ctx)
+	return result, err
+}
+
+func (u *Updater) check(ctx context.Context) (*Result, *ghrelease.Release, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.config.RequestTimeout)
+	defer cancel()
+
+	release, err := u.source.LatestRelease(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get latest release: %w", err)
+	}
+
+	result := &Result{
+		PreviousVersion: u.config.CurrentVersion,
+		Version:         release.Tag,
+		Available:       isNewer(release.Tag, u.config.CurrentVersion),
+		Executable:      u.config.Executable,
+	}
+	if !result.Available {
+		return result, release, nil
+	}
+
+	asset, err := u.selectAsset(release)
+	if err != nil {
+		return nil, nil, err
+	}
+	result.Asset = *asset
+	return result, release, nil
+}
+
+func (u *Updater) Update(ctx context.Context) (*Result, error) {
+	result, release, err := u.check(ctx)
+	if err != nil || !result.Available {
+		return result, err
+	}
+
+	data, err := u.download(ctx, result.Asset)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", result.Asset.Name, err)
+	}
+
+	result.Verified, err = u.verify(ctx, release, result.Asset, data)
+	if err != nil {
+		return nil, err
+	}
+
+	binary, err := extractBinary(result.Asset.Name, data, u.config.BinaryName)
+	if err != nil {
+		return nil, fmt.Errorf("extract %s: %w", result.Asset.Name, err)
+	}
+
+	if err := replaceExecutable(u.config.Executable, binary, u.config.Validate); err != nil {
+		return nil, fmt.Errorf("replace executable: %w", err)
+	}
+	result.Updated = true
+
+	if u.config.Restart {
+		if err := Restart(u.config.Executable); err != nil {
+			return result, fmt.Errorf("restart: %w", err)
+		}
+	}
+	return result, nil
+}
+
+func (u *Updater) download(ctx context.Context, asset ghrelease.Asset) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.config.DownloadTimeout)
+	defer cancel()
+
+	rc, err := u.source.OpenAsset(ctx, asset)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+func parseTemplates(patterns []string) ([]*template.Template, error) {
+	templates := make([]*template.Template, len(patterns))
+	for i, pattern := range patterns {
+		tmpl, err := template.New(pattern).Option("missingkey=error").Parse(pattern)
+		if err != nil {
+			return nil, err
+		}
+		templates[i] = tmpl
+	}
+	return templates, nil
+}
+
+func executeTemplate(tmpl *template.Template, data templateData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func normalizeVersion(version string) string {
+	return strings.TrimPrefix(version, "v")
+}
+
+func isNewer(version, current string) bool {
+	return current == "" || ghrelease.CompareVersions(version, current) > 0
+}
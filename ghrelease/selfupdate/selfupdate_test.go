@@ -0,0 +1,436 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/workpi-ai/go-utils/ghrelease"
+)
+
+type fakeAsset struct {
+	name    string
+	content []byte
+}
+
+func newFakeSource(t *testing.T, tag string, assets []fakeAsset) *ghrelease.GitHubSource {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	mux.HandleFunc("/repos/owner/repo/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		list := make([]map[string]any, len(assets))
+		for i, asset := range assets {
+			list[i] = map[string]any{
+				"id":                   i + 1,
+				"name":                 asset.name,
+				"size":                 len(asset.content),
+				"browser_download_url": server.URL + "/download/" + asset.name,
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"tag_name": tag, "assets": list})
+	})
+	mux.HandleFunc("/download/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/download/")
+		for _, asset := range assets {
+			if asset.name == name {
+				w.Write(asset.content)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatalf("failed to parse server url: %v", err)
+	}
+	client := github.NewClient(nil)
+	client.BaseURL = baseURL
+	return &ghrelease.GitHubSource{Owner: "owner", Repo: "repo", Client: client}
+}
+
+func createTarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("failed to write tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write tar entry: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to close gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func createZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("failed to create zip entry: %v", err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write zip entry: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func writeExecutable(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tool")
+	if err := os.WriteFile(path, []byte(content), 0750); err != nil {
+		t.Fatalf("failed to write executable: %v", err)
+	}
+	return path
+}
+
+func platformName(sep string) string {
+	return "tool" + sep + runtime.GOOS + sep + runtime.GOARCH
+}
+
+func TestUpdater_Update(t *testing.T) {
+	tarball := createTarGz(t, map[string]string{"tool_2.0.0/README.md": "readme", "tool_2.0.0/tool": "new binary"})
+	zipball := createZip(t, map[string]string{"tool": "new binary"})
+	rawName := platformName("-")
+	if runtime.GOOS == "windows" {
+		rawName += ".exe"
+	}
+
+	tests := []struct {
+		name         string
+		assets       []fakeAsset
+		config       Config
+		wantVerified bool
+		wantErr      string
+	}{
+		{
+			name: "tar.gz with checksums",
+			assets: []fakeAsset{
+				{name: platformName("_") + ".tar.gz", content: tarball},
+				{name: "checksums.txt", content: []byte(sha256Hex(tarball) + "  " + platformName("_") + ".tar.gz\n")},
+			},
+			wantVerified: true,
+		},
+		{
+			name:   "zip without checksums",
+			assets: []fakeAsset{{name: platformName("-") + ".zip", content: zipball}},
+			config: Config{AllowUnverified: true},
+		},
+		{
+			name:         "raw binary with sha256 file",
+			assets:       []fakeAsset{{name: rawName, content: []byte("new binary")}, {name: rawName + ".sha256", content: []byte(sha256Hex([]byte("new binary")))}},
+			wantVerified: true,
+		},
+		{
+			name: "checksum mismatch",
+			assets: []fakeAsset{
+				{name: platformName("_") + ".tar.gz", content: tarball},
+				{name: "checksums.txt", content: []byte(sha256Hex([]byte("other")) + "  " + platformName("_") + ".tar.gz\n")},
+			},
+			wantErr: "checksum mismatch",
+		},
+		{
+			name: "skips formats that cannot be extracted",
+			assets: []fakeAsset{
+				{name: platformName("_") + ".tar.xz", content: []byte("xz data")},
+				{name: platformName("-") + ".zip", content: zipball},
+			},
+			config: Config{AllowUnverified: true},
+		},
+		{
+			name:    "checksum required by default",
+			assets:  []fakeAsset{{name: platformName("-") + ".zip", content: zipball}},
+			wantErr: "no checksum asset",
+		},
+		{
+			name:    "no matching asset",
+			assets:  []fakeAsset{{name: "tool_plan9_mips.tar.gz", content: tarball}},
+			wantErr: "tool_plan9_mips.tar.gz",
+		},
+		{
+			name:    "binary missing from archive",
+			assets:  []fakeAsset{{name: platformName("-") + ".zip", content: createZip(t, map[string]string{"other": "x"})}},
+			config:  Config{AllowUnverified: true},
+			wantErr: "not found",
+		},
+		{
+			name:   "validation rejects binary",
+			assets: []fakeAsset{{name: platformName("-") + ".zip", content: zipball}},
+			config: Config{AllowUnverified: true, Validate: func(path string) error {
+				return os.ErrInvalid
+			}},
+			wantErr: "invalid argument",
+		},
+		{
+			name: "explicit matcher with aliases",
+			assets: []fakeAsset{
+				{name: "tool_Linux_arm64.tar.gz", content: createTarGz(t, map[string]string{"tool": "wrong binary"})},
+				{name: "tool_Linux_x86_64.tar.gz", content: tarball},
+			},
+			config: Config{AllowUnverified: true, Matcher: &ghrelease.AssetMatcher{OS: "linux", Arch: "amd64"}},
+		},
+		{
+			name:   "custom template",
+			assets: []fakeAsset{{name: "tool-2.0.0-" + runtime.GOOS + ".bin", content: []byte("new binary")}},
+			config: Config{AllowUnverified: true, AssetTemplates: []string{"{{.Name}}-{{.VersionNumber}}-{{.OS}}.bin"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exe := writeExecutable(t, "old binary")
+			config := tt.config
+			config.Source = newFakeSource(t, "v2.0.0", tt.assets)
+			config.CurrentVersion = "v1.0.0"
+			config.Executable = exe
+
+			updater, err := New(config)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			result, err := updater.Update(context.Background())
+			content, readErr := os.ReadFile(exe)
+			if readErr != nil {
+				t.Fatalf("failed to read executable: %v", readErr)
+			}
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Update() error = %v, want %q", err, tt.wantErr)
+				}
+				if string(content) != "old binary" {
+					t.Errorf("executable replaced despite error: %q", content)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if !result.Updated || result.Version != "v2.0.0" || result.PreviousVersion != "v1.0.0" {
+				t.Errorf("Update() = %+v", result)
+			}
+			if result.Verified != tt.wantVerified {
+				t.Errorf("Verified = %v, want %v", result.Verified, tt.wantVerified)
+			}
+			if string(content) != "new binary" {
+				t.Errorf("executable content = %q, want new binary", content)
+			}
+
+			info, err := os.Stat(exe)
+			if err != nil {
+				t.Fatalf("failed to stat executable: %v", err)
+			}
+			if runtime.GOOS != "windows" && info.Mode().Perm() != 0750 {
+				t.Errorf("mode = %v, want 0750", info.Mode().Perm())
+			}
+			entries, _ := os.ReadDir(filepath.Dir(exe))
+			if len(entries) != 1 {
+				t.Errorf("leftover files next to executable: %v", entries)
+			}
+		})
+	}
+}
+
+func TestUpdater_Update_manifestChecksum(t *testing.T) {
+	zipball := createZip(t, map[string]string{"tool": "new binary"})
+	assetName := platformName("-") + ".zip"
+
+	tests := []struct {
+		name    string
+		sha256  string
+		wantErr string
+	}{
+		{name: "matching checksum", sha256: sha256Hex(zipball)},
+		{name: "mismatched checksum", sha256: sha256Hex([]byte("other")), wantErr: "checksum mismatch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, assetName), zipball, 0644); err != nil {
+				t.Fatal(err)
+			}
+			manifest, err := json.Marshal(ghrelease.Manifest{Releases: []ghrelease.ManifestRelease{{
+				Version: "v2.0.0",
+				Archive: ghrelease.ManifestFile{URL: assetName},
+				Assets:  []ghrelease.ManifestAsset{{Name: assetName, URL: assetName, SHA256: tt.sha256}},
+			}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			manifestPath := filepath.Join(dir, "manifest.json")
+			if err := os.WriteFile(manifestPath, manifest, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			exe := writeExecutable(t, "old binary")
+			updater, err := New(Config{
+				Source:         ghrelease.NewManifestSource((&url.URL{Scheme: "file", Path: filepath.ToSlash(manifestPath)}).String()),
+				CurrentVersion: "v1.0.0",
+				Executable:     exe,
+			})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			result, err := updater.Update(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Update() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if !result.Updated || !result.Verified {
+				t.Errorf("Update() = %+v, want updated and verified", result)
+			}
+		})
+	}
+}
+
+func TestUpdater_Update_noMatchingAsset(t *testing.T) {
+	updater, err := New(Config{
+		Source:         newFakeSource(t, "v2.0.0", []fakeAsset{{name: "tool_plan9_mips.tar.gz"}}),
+		CurrentVersion: "v1.0.0",
+		Executable:     writeExecutable(t, "old binary"),
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	_, err = updater.Update(context.Background())
+	if !errors.Is(err, ghrelease.ErrNoMatchingAsset) {
+		t.Fatalf("Update() error = %v, want ErrNoMatchingAsset", err)
+	}
+	if !strings.Contains(err.Error(), "os plan9 does not match") {
+		t.Errorf("error does not explain rejection: %v", err)
+	}
+}
+
+func TestUpdater_Update_upToDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		latest  string
+		current string
+	}{
+		{name: "same version", latest: "v1.0.0", current: "1.0.0"},
+		{name: "older latest", latest: "v1.0.0", current: "v1.2.0"},
+		{name: "prerelease of current", latest: "v1.2.0-rc.1", current: "v1.2.0"},
+		{name: "numeric ordering", latest: "v1.9.0", current: "v1.10.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exe := writeExecutable(t, "old binary")
+			updater, err := New(Config{
+				Source:         newFakeSource(t, tt.latest, nil),
+				CurrentVersion: tt.current,
+				Executable:     exe,
+			})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			result, err := updater.Update(context.Background())
+			if err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if result.Available || result.Updated {
+				t.Errorf("Update() = %+v, want up to date", result)
+			}
+			if content, _ := os.ReadFile(exe); string(content) != "old binary" {
+				t.Error("Update() replaced the executable with an older version")
+			}
+		})
+	}
+}
+
+func TestUpdater_Check(t *testing.T) {
+	exe := writeExecutable(t, "old binary")
+	name := platformName("_") + ".tar.gz"
+	updater, err := New(Config{
+		Source:         newFakeSource(t, "v2.0.0", []fakeAsset{{name: name, content: []byte("x")}}),
+		CurrentVersion: "v1.0.0",
+		Executable:     exe,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	result, err := updater.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if !result.Available || result.Updated || result.Asset.Name != name {
+		t.Errorf("Check() = %+v", result)
+	}
+	if content, _ := os.ReadFile(exe); string(content) != "old binary" {
+		t.Error("Check() modified the executable")
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{name: "repo", config: Config{RepoOwner: "owner", RepoName: "repo"}},
+		{name: "missing owner", config: Config{RepoName: "repo"}, wantErr: true},
+		{name: "missing repo", config: Config{RepoOwner: "owner"}, wantErr: true},
+		{name: "invalid template", config: Config{RepoOwner: "owner", RepoName: "repo", AssetTemplates: []string{"{{.Name"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updater, err := New(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (updater.config.Executable == "" || updater.config.BinaryName == "") {
+				t.Errorf("New() did not default executable: %+v", updater.config)
+			}
+		})
+	}
+}
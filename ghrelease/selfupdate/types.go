@@ -0,0 +1,42 @@
+package selfupdate
+
+import (
+	"text/template"
+	"time"
+
+	"github.com/workpi-ai/go-utils/ghrelease"
+)
+
+type Config struct {
+	RepoOwner         string
+	RepoName          string
+	Source            ghrelease.Source
+	CurrentVersion    string
+	BinaryName        string
+	AssetTemplates    []string
+	Matcher           *ghrelease.AssetMatcher
+	ChecksumTemplates []string
+	AllowUnverified   bool
+	Executable        string
+	Validate          func(path string) error
+	Restart           bool
+	RequestTimeout    time.Duration
+	DownloadTimeout   time.Duration
+}
+
+type Updater struct {
+	config            Config
+	source            ghrelease.Source
+	assetTemplates    []*template.Template
+	checksumTemplates []*template.Template
+}
+
+type Result struct {
+	PreviousVersion string
+	Version         string
+	Available       bool
+	Updated         bool
+	Verified        bool
+	Asset           ghrelease.Asset
+	Executable      string
+}
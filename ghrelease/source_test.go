@@ -0,0 +1,117 @@
+package ghrelease
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+)
+
+type fakeSource struct {
+	t        *testing.T
+	mu       sync.Mutex
+	latest   string
+	releases map[string]map[string]string
+	opened   int
+}
+
+func (s *fakeSource) setLatest(tag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latest = tag
+}
+
+func (s *fakeSource) ListReleases(ctx context.Context) ([]*Release, error) {
+	tags := make([]string, 0, len(s.releases))
+	for tag := range s.releases {
+		tags = append(tags, tag)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(tags)))
+
+	releases := make([]*Release, len(tags))
+	for i, tag := range tags {
+		releases[i] = &Release{Tag: tag, ArchiveURL: "fake://" + tag}
+	}
+	return releases, nil
+}
+
+func (s *fakeSource) LatestRelease(ctx context.Context) (*Release, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ReleaseByTag(ctx, s.latest)
+}
+
+func (s *fakeSource) ReleaseByTag(ctx context.Context, tag string) (*Release, error) {
+	if _, ok := s.releases[tag]; !ok {
+		return nil, fmt.Errorf("release %s not found", tag)
+	}
+	return &Release{Tag: tag, ArchiveURL: "fake://" + tag}, nil
+}
+
+func (s *fakeSource) OpenArchive(ctx context.Context, release *Release) (io.ReadCloser, error) {
+	files, ok := s.releases[release.Tag]
+	if !ok {
+		return nil, fmt.Errorf("release %s not found", release.Tag)
+	}
+	s.mu.Lock()
+	s.opened++
+	s.mu.Unlock()
+
+	rooted := make(map[string]string, len(files))
+	for name, content := range files {
+		rooted["root/"+name] = content
+	}
+	return io.NopCloser(bytes.NewReader(createTestZip(s.t, rooted))), nil
+}
+
+func (s *fakeSource) OpenAsset(ctx context.Context, asset Asset) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("asset %s not found", asset.Name)
+}
+
+func TestUpdater_customSource(t *testing.T) {
+	tmpDir := t.TempDir()
+	destDir := filepath.Join(tmpDir, "dest")
+	source := &fakeSource{t: t, latest: "v1", releases: map[string]map[string]string{
+		"v1": {"a.md": "a1"},
+		"v2": {"a.md": "a2", "b.md": "b2"},
+	}}
+
+	updater, err := NewUpdater(UpdaterConfig{
+		Source:       source,
+		MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+		Targets:      []ExtractTarget{{PathTransformer: &KeepAllTransformer{}, DestDir: destDir}},
+	})
+	if err != nil {
+		t.Fatalf("NewUpdater() error = %v", err)
+	}
+
+	if _, err := updater.UpdateWithResult(context.Background()); err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	source.setLatest("v2")
+	result, err := updater.UpdateWithResult(context.Background())
+	if err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	if result.PreviousVersion != "v1" || result.Version != "v2" || result.Targets[0].Written != 2 {
+		t.Errorf("UpdateWithResult() = %+v", result)
+	}
+
+	content, err := os.ReadFile(filepath.Join(destDir, "b.md"))
+	if err != nil || string(content) != "b2" {
+		t.Errorf("b.md = %q, %v", content, err)
+	}
+
+	diff, err := updater.Diff(context.Background(), "v1", "v2")
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if diff.Stats.FilesChanged != 2 {
+		t.Errorf("Diff() files changed = %d, want 2", diff.Stats.FilesChanged)
+	}
+}
@@ -0,0 +1,61 @@
+package ghrelease
+
+import (
+	"sort"
+)
+
+func (u *Updater) Subscribe(fn func(ChangeEvent)) func() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.subscribers == nil {
+		u.subscribers = make(map[int]func(ChangeEvent))
+	}
+	id := u.nextSubscriber
+	u.nextSubscriber++
+	u.subscribers[id] = fn
+
+	return func() {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		delete(u.subscribers, id)
+	}
+}
+
+func (u *Updater) notify(result *UpdateResult) {
+	if !result.Changed() && result.PreviousVersion == result.Version {
+		return
+	}
+
+	u.mu.Lock()
+	ids := make([]int, 0, len(u.subscribers))
+	for id := range u.subscribers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	subscribers := make([]func(ChangeEvent), len(ids))
+	for i, id := range ids {
+		subscribers[i] = u.subscribers[id]
+	}
+	u.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(newChangeEvent(result))
+	}
+}
+
+func newChangeEvent(result *UpdateResult) ChangeEvent {
+	event := ChangeEvent{
+		PreviousVersion: result.PreviousVersion,
+		Version:         result.Version,
+		Targets:         make([]TargetChange, len(result.Targets)),
+	}
+	for i, target := range result.Targets {
+		event.Targets[i] = TargetChange{
+			DestDir: target.DestDir,
+			Written: append([]string(nil), target.WrittenFiles...),
+			Removed: append([]string(nil), target.RemovedFiles...),
+		}
+	}
+	return event
+}
@@ -0,0 +1,127 @@
+package ghrelease
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUpdater_Subscribe(t *testing.T) {
+	tmpDir := t.TempDir()
+	agentsDir := filepath.Join(tmpDir, "agents")
+	docsDir := filepath.Join(tmpDir, "docs")
+
+	latest := "v1.0.0"
+	server := newFakeGitHub(t, &latest, map[string]fakeRelease{
+		"v1.0.0": {tag: "v1.0.0", files: map[string]string{
+			"agents/keep.md": "keep",
+			"agents/old.md":  "old",
+			"docs/guide.md":  "guide",
+		}},
+		"v1.1.0": {tag: "v1.1.0", files: map[string]string{
+			"agents/keep.md": "keep",
+			"agents/new.md":  "new",
+			"docs/guide.md":  "guide",
+		}},
+	})
+
+	updater := mustNewUpdater(t, UpdaterConfig{
+		MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+		Targets: []ExtractTarget{
+			{PathTransformer: &SubDirTransformer{SubDir: "agents"}, DestDir: agentsDir},
+			{PathTransformer: &SubDirTransformer{SubDir: "docs"}, DestDir: docsDir},
+		},
+	})
+	useFakeGitHub(t, updater, server)
+
+	var events []ChangeEvent
+	unsubscribe := updater.Subscribe(func(event ChangeEvent) {
+		events = append(events, event)
+		if _, err := updater.Check(context.Background()); err != nil {
+			t.Errorf("Check() from subscriber error = %v", err)
+		}
+	})
+
+	if _, err := updater.UpdateWithResult(context.Background()); err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("events = %d, want 1", len(events))
+	}
+	if events[0].PreviousVersion != "" || events[0].Version != "v1.0.0" {
+		t.Errorf("event versions = %q -> %q", events[0].PreviousVersion, events[0].Version)
+	}
+
+	if _, err := updater.UpdateWithResult(context.Background()); err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("no-op update fired an event: %+v", events[1:])
+	}
+
+	latest = "v1.1.0"
+	if _, err := updater.UpdateWithResult(context.Background()); err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("events = %d, want 2", len(events))
+	}
+	want := ChangeEvent{
+		PreviousVersion: "v1.0.0",
+		Version:         "v1.1.0",
+		Targets: []TargetChange{
+			{DestDir: agentsDir, Written: []string{filepath.Join(agentsDir, "new.md")}, Removed: []string{filepath.Join(agentsDir, "old.md")}},
+			{DestDir: docsDir},
+		},
+	}
+	if !reflect.DeepEqual(events[1], want) {
+		t.Errorf("event = %+v, want %+v", events[1], want)
+	}
+
+	unsubscribe()
+	latest = "v1.0.0"
+	if _, err := updater.UpdateWithResult(context.Background()); err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	if len(events) != 2 {
+		t.Errorf("unsubscribed callback fired")
+	}
+}
+
+func TestUpdater_Subscribe_apply(t *testing.T) {
+	tmpDir := t.TempDir()
+	latest := "v1.0.0"
+	server := newFakeGitHub(t, &latest, map[string]fakeRelease{
+		"v1.0.0": {tag: "v1.0.0", files: map[string]string{"a.md": "a"}},
+	})
+
+	destDir := filepath.Join(tmpDir, "dest")
+	updater := mustNewUpdater(t, UpdaterConfig{
+		MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+		Targets:      []ExtractTarget{{PathTransformer: &KeepAllTransformer{}, DestDir: destDir}},
+	})
+	useFakeGitHub(t, updater, server)
+
+	var first, second []ChangeEvent
+	updater.Subscribe(func(event ChangeEvent) { first = append(first, event) })
+	updater.Subscribe(func(event ChangeEvent) { second = append(second, event) })
+
+	plan, err := updater.Plan(context.Background())
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(first) != 0 {
+		t.Fatal("Plan() should not fire change events")
+	}
+	if _, err := updater.Apply(context.Background(), plan); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	if len(first) != 1 || len(second) != 1 {
+		t.Fatalf("events = %d, %d, want 1 each", len(first), len(second))
+	}
+	if got := first[0].Targets[0].Written; !reflect.DeepEqual(got, []string{filepath.Join(destDir, "a.md")}) {
+		t.Errorf("Written = %v", got)
+	}
+}
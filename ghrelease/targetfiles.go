@@ -0,0 +1,32 @@
+package ghrelease
+
+import (
+	"os"
+	"path/filepath"
+)
+
+type targetFiles struct {
+	targets []ExtractTarget
+}
+
+func (f *targetFiles) Wants(path string) bool {
+	for _, target := range f.targets {
+		if target.PathTransformer.Transform(path) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *targetFiles) Read(path string) ([]byte, bool) {
+	for _, target := range f.targets {
+		destPath := target.PathTransformer.Transform(path)
+		if destPath == "" {
+			continue
+		}
+		if content, err := os.ReadFile(filepath.Join(target.DestDir, destPath)); err == nil {
+			return content, true
+		}
+	}
+	return nil, false
+}
@@ -1,6 +1,11 @@
 package ghrelease
 
 import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/go-github/v68/github"
@@ -9,27 +14,439 @@ import (
 type ExtractTarget struct {
 	PathTransformer PathTransformer
 	DestDir         string
+	Mirror          bool
+	ConflictPolicy  ConflictPolicy
+	MergeFallback   ConflictPolicy
 }
 
 type UpdaterConfig struct {
 	RepoOwner       string
 	RepoName        string
+	Source          Source
+	Sources         []Source
 	MetadataFile    string
 	Targets         []ExtractTarget
+	PristineDir     string
 	RequestTimeout  time.Duration
 	DownloadTimeout time.Duration
+	CacheTTL        time.Duration
+	LockTimeout     time.Duration
+	StagingDir      string
+	PreInstall      []PreInstallHook
+	PostInstall     []PostInstallHook
+}
+
+type Source interface {
+	ListReleases(ctx context.Context) ([]*Release, error)
+	LatestRelease(ctx context.Context) (*Release, error)
+	ReleaseByTag(ctx context.Context, tag string) (*Release, error)
+	OpenArchive(ctx context.Context, release *Release) (io.ReadCloser, error)
+	OpenAsset(ctx context.Context, asset Asset) (io.ReadCloser, error)
+}
+
+type SubDirSource interface {
+	OpenSubDirs(ctx context.Context, release *Release, dirs []string) (io.ReadCloser, error)
+}
+
+type DeltaSource interface {
+	OpenDelta(ctx context.Context, from string, to *Release, installed InstalledFiles) (io.ReadCloser, error)
+}
+
+type InstalledFiles interface {
+	Wants(path string) bool
+	Read(path string) ([]byte, bool)
 }
 
 type PathTransformer interface {
 	Transform(filename string) string
 }
 
+type HookEvent struct {
+	PreviousVersion string
+	Version         string
+	StagingDir      string
+	ReleaseDir      string
+	TargetDirs      []string
+}
+
+type PreInstallHook interface {
+	PreInstall(ctx context.Context, event HookEvent) error
+}
+
+type PostInstallHook interface {
+	PostInstall(ctx context.Context, event HookEvent) error
+}
+
+type ScriptHook struct {
+	Path    string
+	Args    []string
+	Timeout time.Duration
+}
+
 type Updater struct {
-	config UpdaterConfig
-	client *github.Client
+	config        UpdaterConfig
+	source        Source
+	pristine      *pristineStore
+	cachedRelease *Release
+	cachedAt      time.Time
+
+	mu     sync.Mutex
+	runMu  sync.Mutex
+	flight *updateCall
+
+	subscribers    map[int]func(ChangeEvent)
+	nextSubscriber int
 }
 
 type Metadata struct {
-	Version     string `json:"version"`
-	LastCheckAt string `json:"last_check_at"`
+	Version     string            `json:"version"`
+	LastCheckAt string            `json:"last_check_at"`
+	Commit      string            `json:"commit,omitempty"`
+	Ref         string            `json:"ref,omitempty"`
+	CommitDate  string            `json:"commit_date,omitempty"`
+	Files       map[string]string `json:"files,omitempty"`
+}
+
+type ConflictPolicy string
+
+const (
+	ConflictOverwrite   ConflictPolicy = "overwrite"
+	ConflictKeepLocal   ConflictPolicy = "keep-local"
+	ConflictWriteNew    ConflictPolicy = "write-new"
+	ConflictBackupLocal ConflictPolicy = "backup-local"
+	ConflictMerge       ConflictPolicy = "merge"
+)
+
+type Conflict struct {
+	Path      string
+	Policy    ConflictPolicy
+	Removed   bool
+	SavedPath string
+}
+
+type MergeStatus string
+
+const (
+	MergeClean     MergeStatus = "clean"
+	MergeConflicts MergeStatus = "conflicts"
+	MergeFallback  MergeStatus = "fallback"
+)
+
+type MergeResult struct {
+	Path      string
+	Status    MergeStatus
+	Conflicts int
+	Fallback  ConflictPolicy
+}
+
+type UpdateReason string
+
+const (
+	UpdateReasonVersionChanged UpdateReason = "version-changed"
+	UpdateReasonRedownload     UpdateReason = "redownload"
+)
+
+type TargetResult struct {
+	DestDir      string
+	Written      int
+	Skipped      int
+	Removed      int
+	WrittenFiles []string
+	RemovedFiles []string
+}
+
+type PhaseDurations struct {
+	Resolve  time.Duration
+	Download time.Duration
+	Plan     time.Duration
+	Extract  time.Duration
+	Prune    time.Duration
+	Hooks    time.Duration
+	Total    time.Duration
+}
+
+type UpdateResult struct {
+	PreviousVersion string
+	Version         string
+	Downloaded      bool
+	Reason          UpdateReason
+	Targets         []TargetResult
+	Durations       PhaseDurations
+	Conflicts       []Conflict
+	Merges          []MergeResult
+	Warnings        []string
+}
+
+type Asset struct {
+	ID          int64
+	Name        string
+	Size        int64
+	ContentType string
+	DownloadURL string
+	SHA256      string
+}
+
+type Release struct {
+	Tag           string
+	Name          string
+	Body          string
+	HTMLURL       string
+	PublishedAt   time.Time
+	Prerelease    bool
+	ArchiveURL    string
+	ArchiveSHA256 string
+	FlatArchive   bool
+	Commit        string
+	Ref           string
+	Assets        []Asset
+}
+
+type CheckResult struct {
+	LocalVersion    string
+	LatestVersion   string
+	Release         *Release
+	UpdateAvailable bool
+	Reason          UpdateReason
+}
+
+type PlanAction string
+
+const (
+	PlanCreate    PlanAction = "create"
+	PlanOverwrite PlanAction = "overwrite"
+	PlanDelete    PlanAction = "delete"
+	PlanUnchanged PlanAction = "unchanged"
+)
+
+type PlanOperation struct {
+	Action    PlanAction     `json:"action"`
+	Path      string         `json:"path"`
+	DestDir   string         `json:"dest_dir"`
+	Target    int            `json:"target"`
+	Size      int64          `json:"size"`
+	Hash      string         `json:"hash,omitempty"`
+	LocalHash string         `json:"local_hash,omitempty"`
+	Conflict  ConflictPolicy `json:"conflict,omitempty"`
+	Mirror    bool           `json:"mirror,omitempty"`
+}
+
+type Plan struct {
+	PreviousVersion string          `json:"previous_version"`
+	Version         string          `json:"version"`
+	Reason          UpdateReason    `json:"reason,omitempty"`
+	Operations      []PlanOperation `json:"operations"`
+
+	updater  *Updater
+	release  *Release
+	previous map[string]string
+	files    map[string]string
+	contents map[string][]byte
+	entries  []archiveEntry
+	warnings []string
+}
+
+type FileStatus string
+
+const (
+	FileAdded    FileStatus = "added"
+	FileModified FileStatus = "modified"
+	FileRemoved  FileStatus = "removed"
+)
+
+type FileDiff struct {
+	Path      string
+	Status    FileStatus
+	Binary    bool
+	Additions int
+	Deletions int
+	Patch     string
+}
+
+type DiffStats struct {
+	FilesChanged int
+	Additions    int
+	Deletions    int
+}
+
+type ReleaseDiff struct {
+	From  string
+	To    string
+	Files []FileDiff
+	Stats DiffStats
+}
+
+type VerifyResult struct {
+	Version  string
+	Files    int
+	Missing  []string
+	Modified []string
+}
+
+type SchedulerConfig struct {
+	Interval   time.Duration
+	Jitter     time.Duration
+	RetryDelay time.Duration
+	MaxBackoff time.Duration
+	OnResult   func(SchedulerEvent)
+}
+
+type SchedulerEvent struct {
+	Result    *UpdateResult
+	Err       error
+	Triggered bool
+	Failures  int
+	Next      time.Duration
+}
+
+type Scheduler struct {
+	updater *Updater
+	config  SchedulerConfig
+	trigger chan struct{}
+	results chan SchedulerEvent
+	running atomic.Bool
+}
+
+type TargetChange struct {
+	DestDir string
+	Written []string
+	Removed []string
+}
+
+type ChangeEvent struct {
+	PreviousVersion string
+	Version         string
+	Targets         []TargetChange
+}
+
+type GitHubSource struct {
+	Owner            string
+	Repo             string
+	Branch           string
+	TagPattern       string
+	TreeFetch        bool
+	Incremental      bool
+	BlobCacheDir     string
+	FetchConcurrency int
+	Client           *github.Client
+	HTTPClient       *http.Client
+}
+
+type AssetMatcher struct {
+	OS         string
+	Arch       string
+	ARMVersion int
+	Libc       string
+	Name       string
+}
+
+type AssetMatch struct {
+	Asset    Asset
+	Accepted bool
+	Score    int
+	Reasons  []string
+}
+
+type GitLabSource struct {
+	BaseURL      string
+	Project      string
+	PrivateToken string
+	JobToken     string
+	UseTags      bool
+	HTTPClient   *http.Client
+}
+
+type GiteaSource struct {
+	BaseURL    string
+	Owner      string
+	Repo       string
+	Token      string
+	HTTPClient *http.Client
+}
+
+type Manifest struct {
+	Name     string            `json:"name,omitempty"`
+	Releases []ManifestRelease `json:"releases"`
+}
+
+type ManifestRelease struct {
+	Version     string          `json:"version"`
+	Name        string          `json:"name,omitempty"`
+	Notes       string          `json:"notes,omitempty"`
+	PublishedAt time.Time       `json:"published_at"`
+	Channel     string          `json:"channel,omitempty"`
+	Archive     ManifestFile    `json:"archive"`
+	Assets      []ManifestAsset `json:"assets,omitempty"`
+}
+
+type ManifestFile struct {
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256,omitempty"`
+	Size   int64  `json:"size,omitempty"`
+	Flat   bool   `json:"flat,omitempty"`
+}
+
+type ManifestAsset struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256,omitempty"`
+	Size   int64  `json:"size,omitempty"`
+}
+
+type ManifestSource struct {
+	URL        string
+	Channel    string
+	Version    string
+	HTTPClient *http.Client
+}
+
+type DirSource struct {
+	Dir string
+}
+
+type GitRepoSource struct {
+	RepoDir string
+	GitPath string
+}
+
+type OCISource struct {
+	BaseURL    string
+	Repository string
+	Username   string
+	Password   string
+	Token      string
+	HTTPClient *http.Client
+
+	mu   sync.Mutex
+	auth string
+}
+
+type S3Source struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	FlatArchives    bool
+	HTTPClient      *http.Client
+}
+
+type FallbackSource struct {
+	Sources  []Source
+	Cooldown time.Duration
+
+	mu             sync.Mutex
+	health         []SourceHealth
+	cachedTag      string
+	cachedReleases []*Release
+}
+
+type SourceHealth struct {
+	Source      Source
+	Healthy     bool
+	Failures    int
+	LastError   string
+	LastFailure time.Time
+	LastSuccess time.Time
 }
@@ -1,19 +1,16 @@
 package ghrelease
 
 import (
-	"archive/zip"
-	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
-
-	"github.com/google/go-github/v68/github"
 )
 
 const (
@@ -21,14 +18,24 @@ const (
 	defaultDownloadTimeout = 30 * time.Second
 	defaultDirPerm         = 0755
 	defaultFilePerm        = 0644
+	defaultPristineDir     = "pristine"
 )
 
 func NewUpdater(config UpdaterConfig) (*Updater, error) {
-	if config.RepoOwner == "" {
-		return nil, fmt.Errorf("repo owner cannot be empty")
+	if len(config.Sources) > 0 {
+		if config.Source != nil {
+			return nil, fmt.Errorf("source and sources cannot both be set")
+		}
+		config.Source = NewFallbackSource(config.Sources...)
 	}
-	if config.RepoName == "" {
-		return nil, fmt.Errorf("repo name cannot be empty")
+	if config.Source == nil {
+		if config.RepoOwner == "" {
+			return nil, fmt.Errorf("repo owner cannot be empty")
+		}
+		if config.RepoName == "" {
+			return nil, fmt.Errorf("repo name cannot be empty")
+		}
+		config.Source = NewGitHubSource(config.RepoOwner, config.RepoName)
 	}
 	if len(config.Targets) == 0 {
 		return nil, fmt.Errorf("targets cannot be empty")
@@ -41,165 +48,211 @@ func NewUpdater(config UpdaterConfig) (*Updater, error) {
 			return nil, fmt.Errorf("target[%d].DestDir cannot be empty", i)
 		}
 	}
+	usesMerge := false
+	for i, target := range config.Targets {
+		switch target.ConflictPolicy {
+		case "", ConflictOverwrite, ConflictKeepLocal, ConflictWriteNew, ConflictBackupLocal:
+		case ConflictMerge:
+			usesMerge = true
+		default:
+			return nil, fmt.Errorf("target[%d].ConflictPolicy %q is invalid", i, target.ConflictPolicy)
+		}
+		switch target.MergeFallback {
+		case "", ConflictOverwrite, ConflictKeepLocal, ConflictWriteNew, ConflictBackupLocal:
+		default:
+			return nil, fmt.Errorf("target[%d].MergeFallback %q is invalid", i, target.MergeFallback)
+		}
+	}
+	if usesMerge && config.PristineDir == "" {
+		if config.MetadataFile == "" {
+			return nil, fmt.Errorf("pristine dir or metadata file is required for merge conflict policy")
+		}
+		config.PristineDir = filepath.Join(filepath.Dir(config.MetadataFile), defaultPristineDir)
+	}
 	if config.RequestTimeout == 0 {
 		config.RequestTimeout = defaultRequestTimeout
 	}
 	if config.DownloadTimeout == 0 {
 		config.DownloadTimeout = defaultDownloadTimeout
 	}
+	if config.LockTimeout == 0 {
+		config.LockTimeout = defaultLockTimeout
+	}
 
-	return &Updater{
+	updater := &Updater{
 		config: config,
-		client: github.NewClient(nil),
-	}, nil
+		source: config.Source,
+	}
+	if usesMerge {
+		updater.pristine = &pristineStore{dir: config.PristineDir}
+	}
+	return updater, nil
 }
 
 func (u *Updater) Update() error {
-	latestVersion, err := u.getLatestVersion()
-	if err != nil {
-		return fmt.Errorf("get latest version: %w", err)
-	}
-
-	localVersion := u.getLocalVersion()
+	_, err := u.UpdateWithResult(context.Background())
+	return err
+}
 
-	needsDownload := latestVersion != localVersion || u.needsRedownload()
-	if !needsDownload {
-		u.saveLocalVersion(localVersion)
-		return nil
+func (u *Updater) UpdateWithResult(ctx context.Context) (*UpdateResult, error) {
+	u.mu.Lock()
+	if call := u.flight; call != nil {
+		u.mu.Unlock()
+		return call.wait(ctx)
 	}
+	call := &updateCall{done: make(chan struct{})}
+	u.flight = call
+	u.mu.Unlock()
 
-	if err := u.downloadRelease(latestVersion); err != nil {
-		return fmt.Errorf("download release: %w", err)
-	}
+	call.result, call.err = u.update(ctx)
 
-	u.saveLocalVersion(latestVersion)
+	u.mu.Lock()
+	u.flight = nil
+	u.mu.Unlock()
+	close(call.done)
 
-	return nil
+	if call.err == nil {
+		u.notify(call.result)
+	}
+	return call.result, call.err
 }
 
-func (u *Updater) getLatestVersion() (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), u.config.RequestTimeout)
-	defer cancel()
+func (u *Updater) update(ctx context.Context) (*UpdateResult, error) {
+	u.runMu.Lock()
+	defer u.runMu.Unlock()
+
+	start := time.Now()
+	result := u.newUpdateResult()
+	defer func() { result.Durations.Total = time.Since(start) }()
 
-	release, _, err := u.client.Repositories.GetLatestRelease(ctx, u.config.RepoOwner, u.config.RepoName)
+	lock, err := u.acquireLock(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to get latest release: %w", err)
+		return nil, fmt.Errorf("acquire lock: %w", err)
 	}
+	defer lock.unlock()
 
-	if release.TagName == nil {
-		return "", fmt.Errorf("release tag name is nil")
+	release, err := u.latestRelease(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get latest release: %w", err)
 	}
+	result.Durations.Resolve = time.Since(start)
 
-	return *release.TagName, nil
-}
+	metadata := u.loadMetadata()
+	result.PreviousVersion = metadata.Version
+	result.Version = release.Tag
+	result.Reason = u.updateReason(metadata, release.Tag)
 
-func (u *Updater) getLocalVersion() string {
-	data, err := os.ReadFile(u.config.MetadataFile)
-	if err != nil {
-		return ""
+	if result.Reason == "" {
+		if err := u.saveMetadata(metadata); err != nil {
+			result.addWarning("save metadata: %v", err)
+		}
+		return result, nil
 	}
 
-	var v Metadata
-	if err := json.Unmarshal(data, &v); err != nil {
-		return ""
+	phaseStart := time.Now()
+	data, warnings, err := u.fetchArchive(ctx, release)
+	if err != nil {
+		return nil, fmt.Errorf("download release: %w", err)
 	}
+	result.Downloaded = true
+	result.Durations.Download = time.Since(phaseStart)
 
-	return v.Version
-}
-
-func (u *Updater) downloadRelease(version string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), u.config.DownloadTimeout)
-	defer cancel()
-
-	release, _, err := u.client.Repositories.GetReleaseByTag(ctx, u.config.RepoOwner, u.config.RepoName, version)
+	phaseStart = time.Now()
+	plan, err := u.buildPlan(release, data, metadata)
 	if err != nil {
-		return fmt.Errorf("failed to get release info: %w", err)
+		return nil, fmt.Errorf("build plan: %w", err)
 	}
+	plan.Reason = result.Reason
+	plan.warnings = warnings
+	result.Durations.Plan = time.Since(phaseStart)
 
-	if release.ZipballURL == nil {
-		return fmt.Errorf("release zipball_url is nil")
+	if err := u.applyPlan(ctx, plan, result); err != nil {
+		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *release.ZipballURL, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	return result, nil
+}
+
+func (u *Updater) newUpdateResult() *UpdateResult {
+	result := &UpdateResult{Targets: make([]TargetResult, len(u.config.Targets))}
+	for i, target := range u.config.Targets {
+		result.Targets[i].DestDir = target.DestDir
 	}
+	return result
+}
 
-	resp, err := http.DefaultClient.Do(req)
+func (u *Updater) getLocalVersion() string {
+	return u.loadMetadata().Version
+}
+
+func (u *Updater) loadMetadata() Metadata {
+	data, err := os.ReadFile(u.config.MetadataFile)
 	if err != nil {
-		return fmt.Errorf("failed to download zipball: %w", err)
+		return Metadata{}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, *release.ZipballURL)
+	var m Metadata
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Metadata{}
 	}
 
-	return u.extractZip(resp.Body)
+	return m
 }
 
-func (u *Updater) extractZip(r io.Reader) error {
-	data, err := io.ReadAll(r)
+func (u *Updater) fetchArchive(ctx context.Context, release *Release) ([]byte, []string, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.config.DownloadTimeout)
+	defer cancel()
+
+	rc, warnings, err := u.openArchive(ctx, release)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
+	defer rc.Close()
 
-	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	data, err := io.ReadAll(rc)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-
-	for _, file := range zipReader.File {
-		if file.FileInfo().IsDir() {
-			continue
-		}
-
-		relPath := u.stripRootDir(file.Name)
-		if relPath == "" {
-			continue
-		}
-
-		for _, target := range u.config.Targets {
-			destPath := target.PathTransformer.Transform(relPath)
-			if destPath == "" {
-				continue
-			}
-
-			fullPath := filepath.Join(target.DestDir, destPath)
-
-			if err := u.extractFile(file, fullPath); err != nil {
-				return err
-			}
+	if _, verified := u.source.(*FallbackSource); !verified && release.ArchiveSHA256 != "" {
+		if hash := hashBytes(data); !strings.EqualFold(hash, release.ArchiveSHA256) {
+			return nil, nil, fmt.Errorf("archive checksum mismatch for %s: got %s, want %s", release.Tag, hash, release.ArchiveSHA256)
 		}
 	}
-
-	return nil
+	return data, warnings, nil
 }
 
-func (u *Updater) extractFile(file *zip.File, destPath string) error {
-	destPath = filepath.Clean(destPath)
-	if !filepath.IsAbs(destPath) {
-		return fmt.Errorf("destination path must be absolute: %s", destPath)
-	}
-
-	if err := os.MkdirAll(filepath.Dir(destPath), defaultDirPerm); err != nil {
+func writeFile(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), defaultDirPerm); err != nil {
 		return err
 	}
 
-	rc, err := file.Open()
+	f, err := os.Create(path)
 	if err != nil {
 		return err
 	}
-	defer rc.Close()
+	defer f.Close()
 
-	f, err := os.Create(destPath)
+	_, err = f.Write(content)
+	return err
+}
+
+func hashBytes(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer f.Close()
 
-	_, err = io.Copy(f, rc)
-	return err
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
 func (u *Updater) needsRedownload() bool {
@@ -226,7 +279,7 @@ func (u *Updater) needsRedownload() bool {
 	return false
 }
 
-func (u *Updater) stripRootDir(filename string) string {
+func stripRootDir(filename string) string {
 	idx := strings.Index(filename, "/")
 	if idx == -1 {
 		return ""
@@ -234,20 +287,40 @@ func (u *Updater) stripRootDir(filename string) string {
 	return filename[idx+1:]
 }
 
-func (u *Updater) saveLocalVersion(version string) error {
+func (u *Updater) saveMetadata(m Metadata) error {
 	if err := os.MkdirAll(filepath.Dir(u.config.MetadataFile), defaultDirPerm); err != nil {
 		return err
 	}
 
-	v := Metadata{
-		Version:     version,
-		LastCheckAt: time.Now().Format(time.RFC3339),
+	m.LastCheckAt = time.Now().Format(time.RFC3339)
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
 	}
 
-	data, err := json.MarshalIndent(v, "", "  ")
+	return writeFileAtomic(u.config.MetadataFile, data, defaultFilePerm)
+}
+
+func writeFileAtomic(path string, content []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
 	if err != nil {
 		return err
 	}
+	tmpPath := f.Name()
 
-	return os.WriteFile(u.config.MetadataFile, data, defaultFilePerm)
+	_, err = f.Write(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+	}
+	return err
 }
@@ -3,9 +3,15 @@ package ghrelease
 import (
 	"archive/zip"
 	"bytes"
+	"context"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -30,6 +36,19 @@ func mustNewUpdater(t *testing.T, config UpdaterConfig) *Updater {
 	return updater
 }
 
+func applyTestArchive(t *testing.T, updater *Updater, data []byte, previous map[string]string) (*Plan, *UpdateResult, error) {
+	t.Helper()
+	if updater.config.MetadataFile == "" {
+		updater.config.MetadataFile = filepath.Join(t.TempDir(), "metadata.json")
+	}
+	plan, err := updater.buildPlan(&Release{Tag: "v1.0.0"}, data, Metadata{Files: previous})
+	if err != nil {
+		return nil, nil, err
+	}
+	result := updater.newUpdateResult()
+	return plan, result, updater.applyPlan(context.Background(), plan, result)
+}
+
 func TestNewUpdater(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -77,6 +96,22 @@ func TestNewUpdater(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "custom source without repo",
+			config: UpdaterConfig{
+				Source:       &fakeSource{},
+				MetadataFile: "/tmp/metadata.json",
+				Targets: []ExtractTarget{
+					{PathTransformer: &KeepAllTransformer{}, DestDir: "/tmp"},
+				},
+			},
+			want: UpdaterConfig{
+				MetadataFile:    "/tmp/metadata.json",
+				RequestTimeout:  defaultRequestTimeout,
+				DownloadTimeout: defaultDownloadTimeout,
+			},
+			wantErr: false,
+		},
 		{
 			name: "empty targets",
 			config: UpdaterConfig{
@@ -168,8 +203,8 @@ func TestNewUpdater(t *testing.T) {
 			if updater.config.DownloadTimeout != tt.want.DownloadTimeout {
 				t.Errorf("DownloadTimeout = %v, want %v", updater.config.DownloadTimeout, tt.want.DownloadTimeout)
 			}
-			if updater.client == nil {
-				t.Error("client is nil")
+			if updater.source == nil {
+				t.Error("source is nil")
 			}
 		})
 	}
@@ -230,7 +265,7 @@ func TestUpdater_getLocalVersion(t *testing.T) {
 	}
 }
 
-func TestUpdater_saveLocalVersion(t *testing.T) {
+func TestUpdater_saveMetadata(t *testing.T) {
 	tmpDir := t.TempDir()
 
 	tests := []struct {
@@ -256,10 +291,10 @@ func TestUpdater_saveLocalVersion(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			updater := mustNewUpdater(t, UpdaterConfig{MetadataFile: tt.metadataFile})
-			err := updater.saveLocalVersion(tt.version)
+			err := updater.saveMetadata(Metadata{Version: tt.version})
 
 			if (err != nil) != tt.wantErr {
-				t.Errorf("saveLocalVersion() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("saveMetadata() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 
@@ -286,9 +321,7 @@ func TestUpdater_saveLocalVersion(t *testing.T) {
 	}
 }
 
-func TestUpdater_stripRootDir(t *testing.T) {
-	updater := mustNewUpdater(t, UpdaterConfig{})
-
+func TestStripRootDir(t *testing.T) {
 	tests := []struct {
 		name     string
 		filename string
@@ -323,7 +356,7 @@ func TestUpdater_stripRootDir(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := updater.stripRootDir(tt.filename)
+			got := stripRootDir(tt.filename)
 			if got != tt.want {
 				t.Errorf("stripRootDir(%q) = %q, want %q", tt.filename, got, tt.want)
 			}
@@ -331,17 +364,17 @@ func TestUpdater_stripRootDir(t *testing.T) {
 	}
 }
 
-func TestUpdater_extractZip(t *testing.T) {
+func TestUpdater_applyPlan(t *testing.T) {
 	tmpDir := t.TempDir()
 	agentsDir := filepath.Join(tmpDir, "agents")
 	commandsDir := filepath.Join(tmpDir, "commands")
 
 	zipData := createTestZip(t, map[string]string{
-		"repo-v1.0.0/agents/foo.md":          "agent foo content",
-		"repo-v1.0.0/agents/bar.md":          "agent bar content",
+		"repo-v1.0.0/agents/foo.md":           "agent foo content",
+		"repo-v1.0.0/agents/bar.md":           "agent bar content",
 		"repo-v1.0.0/commands/code/review.md": "review content",
-		"repo-v1.0.0/README.md":              "readme content",
-		"repo-v1.0.0/other/file.txt":         "other content",
+		"repo-v1.0.0/README.md":               "readme content",
+		"repo-v1.0.0/other/file.txt":          "other content",
 	})
 
 	updater := mustNewUpdater(t, UpdaterConfig{
@@ -357,14 +390,23 @@ func TestUpdater_extractZip(t *testing.T) {
 		},
 	})
 
-	err := updater.extractZip(bytes.NewReader(zipData))
+	plan, _, err := applyTestArchive(t, updater, zipData, nil)
 	if err != nil {
-		t.Fatalf("extractZip() error = %v", err)
+		t.Fatalf("applyPlan() error = %v", err)
+	}
+
+	files := plan.files
+	if len(files) != 3 {
+		t.Errorf("plan has %d files, want 3", len(files))
+	}
+	wantHash := "c3cdab1508ba2ea1594c63f3686a06d675976908e64b776a5c0cd7028277f6eb"
+	if got := files[filepath.Join(agentsDir, "foo.md")]; got != wantHash {
+		t.Errorf("hash of foo.md = %q, want %q", got, wantHash)
 	}
 
 	expectedFiles := map[string]string{
-		filepath.Join(agentsDir, "foo.md"):            "agent foo content",
-		filepath.Join(agentsDir, "bar.md"):            "agent bar content",
+		filepath.Join(agentsDir, "foo.md"):           "agent foo content",
+		filepath.Join(agentsDir, "bar.md"):           "agent bar content",
 		filepath.Join(commandsDir, "code/review.md"): "review content",
 	}
 
@@ -391,7 +433,7 @@ func TestUpdater_extractZip(t *testing.T) {
 	}
 }
 
-func TestUpdater_extractZip_emptyTargets(t *testing.T) {
+func TestUpdater_applyPlan_emptyTargets(t *testing.T) {
 	_, err := NewUpdater(UpdaterConfig{
 		Targets: []ExtractTarget{},
 	})
@@ -401,22 +443,21 @@ func TestUpdater_extractZip_emptyTargets(t *testing.T) {
 	}
 }
 
-func TestUpdater_extractZip_invalidZip(t *testing.T) {
+func TestUpdater_applyPlan_invalidZip(t *testing.T) {
 	updater := mustNewUpdater(t, UpdaterConfig{})
 
-	err := updater.extractZip(bytes.NewReader([]byte("not a zip file")))
-	if err == nil {
-		t.Error("extractZip() should return error for invalid zip")
+	if _, _, err := applyTestArchive(t, updater, []byte("not a zip file"), nil); err == nil {
+		t.Error("buildPlan() should return error for invalid zip")
 	}
 }
 
-func TestUpdater_extractZip_withKeepAllTransformer(t *testing.T) {
+func TestUpdater_applyPlan_withKeepAllTransformer(t *testing.T) {
 	tmpDir := t.TempDir()
 	destDir := filepath.Join(tmpDir, "dest")
 
 	zipData := createTestZip(t, map[string]string{
-		"repo-v1.0.0/file1.txt": "content1",
-		"repo-v1.0.0/file2.md":  "content2",
+		"repo-v1.0.0/file1.txt":    "content1",
+		"repo-v1.0.0/file2.md":     "content2",
 		"repo-v1.0.0/dir/file3.go": "content3",
 	})
 
@@ -429,9 +470,8 @@ func TestUpdater_extractZip_withKeepAllTransformer(t *testing.T) {
 		},
 	})
 
-	err := updater.extractZip(bytes.NewReader(zipData))
-	if err != nil {
-		t.Fatalf("extractZip() error = %v", err)
+	if _, _, err := applyTestArchive(t, updater, zipData, nil); err != nil {
+		t.Fatalf("applyPlan() error = %v", err)
 	}
 
 	expectedFiles := []string{
@@ -447,7 +487,7 @@ func TestUpdater_extractZip_withKeepAllTransformer(t *testing.T) {
 	}
 }
 
-func TestUpdater_extractZip_withExtTransformer(t *testing.T) {
+func TestUpdater_applyPlan_withExtTransformer(t *testing.T) {
 	tmpDir := t.TempDir()
 	destDir := filepath.Join(tmpDir, "dest")
 
@@ -466,9 +506,8 @@ func TestUpdater_extractZip_withExtTransformer(t *testing.T) {
 		},
 	})
 
-	err := updater.extractZip(bytes.NewReader(zipData))
-	if err != nil {
-		t.Fatalf("extractZip() error = %v", err)
+	if _, _, err := applyTestArchive(t, updater, zipData, nil); err != nil {
+		t.Fatalf("applyPlan() error = %v", err)
 	}
 
 	expectedFiles := []string{
@@ -493,7 +532,7 @@ func TestUpdater_extractZip_withExtTransformer(t *testing.T) {
 	}
 }
 
-func TestUpdater_extractZip_multipleTargetsSameFile(t *testing.T) {
+func TestUpdater_applyPlan_multipleTargetsSameFile(t *testing.T) {
 	tmpDir := t.TempDir()
 	destDir1 := filepath.Join(tmpDir, "dest1")
 	destDir2 := filepath.Join(tmpDir, "dest2")
@@ -515,9 +554,8 @@ func TestUpdater_extractZip_multipleTargetsSameFile(t *testing.T) {
 		},
 	})
 
-	err := updater.extractZip(bytes.NewReader(zipData))
-	if err != nil {
-		t.Fatalf("extractZip() error = %v", err)
+	if _, _, err := applyTestArchive(t, updater, zipData, nil); err != nil {
+		t.Fatalf("applyPlan() error = %v", err)
 	}
 
 	for _, dir := range []string{destDir1, destDir2} {
@@ -533,7 +571,7 @@ func TestUpdater_extractZip_multipleTargetsSameFile(t *testing.T) {
 	}
 }
 
-func TestUpdater_extractZip_directoryEntries(t *testing.T) {
+func TestUpdater_applyPlan_directoryEntries(t *testing.T) {
 	tmpDir := t.TempDir()
 	destDir := filepath.Join(tmpDir, "dest")
 
@@ -567,9 +605,8 @@ func TestUpdater_extractZip_directoryEntries(t *testing.T) {
 		},
 	})
 
-	err = updater.extractZip(bytes.NewReader(buf.Bytes()))
-	if err != nil {
-		t.Fatalf("extractZip() error = %v", err)
+	if _, _, err := applyTestArchive(t, updater, buf.Bytes(), nil); err != nil {
+		t.Fatalf("applyPlan() error = %v", err)
 	}
 
 	filePath := filepath.Join(destDir, "file.txt")
@@ -578,52 +615,24 @@ func TestUpdater_extractZip_directoryEntries(t *testing.T) {
 	}
 }
 
-func TestUpdater_extractFile(t *testing.T) {
-	tmpDir := t.TempDir()
-
-	zipData := createTestZip(t, map[string]string{
-		"test.txt": "test content",
-	})
-
-	zipReader, _ := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
-	file := zipReader.File[0]
-
-	updater := mustNewUpdater(t, UpdaterConfig{})
-
-	destPath := filepath.Join(tmpDir, "nested", "dir", "output.txt")
-	err := updater.extractFile(file, destPath)
-	if err != nil {
-		t.Fatalf("extractFile() error = %v", err)
-	}
-
-	content, err := os.ReadFile(destPath)
-	if err != nil {
-		t.Fatalf("failed to read extracted file: %v", err)
-	}
-
-	if string(content) != "test content" {
-		t.Errorf("content = %q, want %q", string(content), "test content")
-	}
-}
-
-func TestUpdater_extractFile_overwrite(t *testing.T) {
+func TestUpdater_applyPlan_overwrite(t *testing.T) {
 	tmpDir := t.TempDir()
+	destDir := filepath.Join(tmpDir, "dest")
 
-	destPath := filepath.Join(tmpDir, "file.txt")
-	os.WriteFile(destPath, []byte("old content"), 0644)
+	destPath := filepath.Join(destDir, "nested", "file.txt")
+	writeTestFiles(t, map[string]string{destPath: "old content"})
 
 	zipData := createTestZip(t, map[string]string{
-		"test.txt": "new content",
+		"repo-v1.0.0/nested/file.txt": "new content",
 	})
 
-	zipReader, _ := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
-	file := zipReader.File[0]
-
-	updater := mustNewUpdater(t, UpdaterConfig{})
+	updater := mustNewUpdater(t, UpdaterConfig{
+		Targets: []ExtractTarget{{PathTransformer: &KeepAllTransformer{}, DestDir: destDir}},
+	})
 
-	err := updater.extractFile(file, destPath)
-	if err != nil {
-		t.Fatalf("extractFile() error = %v", err)
+	previous := map[string]string{destPath: hashBytes([]byte("old content"))}
+	if _, _, err := applyTestArchive(t, updater, zipData, previous); err != nil {
+		t.Fatalf("applyPlan() error = %v", err)
 	}
 
 	content, err := os.ReadFile(destPath)
@@ -724,3 +733,181 @@ func createTestZip(t *testing.T, files map[string]string) []byte {
 
 	return buf.Bytes()
 }
+
+type fakeRelease struct {
+	tag   string
+	files map[string]string
+}
+
+func newFakeGitHub(t *testing.T, latest *string, releases map[string]fakeRelease) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	releaseJSON := func(tag string) map[string]any {
+		return map[string]any{
+			"tag_name":     tag,
+			"name":         "Release " + tag,
+			"body":         "notes for " + tag,
+			"html_url":     "https://github.com/owner/repo/releases/tag/" + tag,
+			"published_at": "2024-01-02T03:04:05Z",
+			"zipball_url":  server.URL + "/zipball/" + tag,
+			"assets": []map[string]any{
+				{"id": 1, "name": "pack.zip", "size": 123, "browser_download_url": server.URL + "/assets/pack.zip"},
+			},
+		}
+	}
+
+	mux.HandleFunc("/repos/owner/repo/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(releaseJSON(*latest))
+	})
+	mux.HandleFunc("/repos/owner/repo/releases/tags/", func(w http.ResponseWriter, r *http.Request) {
+		tag := strings.TrimPrefix(r.URL.Path, "/repos/owner/repo/releases/tags/")
+		if _, ok := releases[tag]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(releaseJSON(tag))
+	})
+	mux.HandleFunc("/zipball/", func(w http.ResponseWriter, r *http.Request) {
+		release, ok := releases[strings.TrimPrefix(r.URL.Path, "/zipball/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		files := make(map[string]string, len(release.files))
+		for name, content := range release.files {
+			files["repo-"+release.tag+"/"+name] = content
+		}
+		w.Write(createTestZip(t, files))
+	})
+
+	return server
+}
+
+func useFakeGitHub(t *testing.T, updater *Updater, server *httptest.Server) {
+	t.Helper()
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatalf("failed to parse server url: %v", err)
+	}
+	updater.source.(*GitHubSource).Client.BaseURL = baseURL
+}
+
+func TestUpdater_UpdateWithResult(t *testing.T) {
+	tmpDir := t.TempDir()
+	agentsDir := filepath.Join(tmpDir, "agents")
+
+	latest := "v1.0.0"
+	server := newFakeGitHub(t, &latest, map[string]fakeRelease{
+		"v1.0.0": {tag: "v1.0.0", files: map[string]string{
+			"agents/keep.md": "keep",
+			"agents/old.md":  "old",
+			"README.md":      "readme",
+		}},
+		"v1.1.0": {tag: "v1.1.0", files: map[string]string{
+			"agents/keep.md": "keep",
+			"agents/new.md":  "new",
+		}},
+	})
+
+	updater := mustNewUpdater(t, UpdaterConfig{
+		MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+		Targets: []ExtractTarget{
+			{PathTransformer: &SubDirTransformer{SubDir: "agents", Ext: ".md"}, DestDir: agentsDir},
+		},
+	})
+	useFakeGitHub(t, updater, server)
+
+	result, err := updater.UpdateWithResult(context.Background())
+	if err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	if !result.Downloaded || result.Reason != UpdateReasonVersionChanged {
+		t.Errorf("Downloaded = %v, Reason = %v, want download for version change", result.Downloaded, result.Reason)
+	}
+	if result.PreviousVersion != "" || result.Version != "v1.0.0" {
+		t.Errorf("versions = %q -> %q, want \"\" -> v1.0.0", result.PreviousVersion, result.Version)
+	}
+	if got := result.Targets[0]; got.Written != 2 || got.Skipped != 0 || got.Removed != 0 {
+		t.Errorf("target result = %+v, want 2 written", got)
+	}
+	if !result.Changed() {
+		t.Error("Changed() = false, want true")
+	}
+
+	result, err = updater.UpdateWithResult(context.Background())
+	if err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	if result.Downloaded || result.Reason != "" || result.Changed() {
+		t.Errorf("second update = %+v, want no-op", result)
+	}
+
+	latest = "v1.1.0"
+	result, err = updater.UpdateWithResult(context.Background())
+	if err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	if result.PreviousVersion != "v1.0.0" || result.Version != "v1.1.0" {
+		t.Errorf("versions = %q -> %q, want v1.0.0 -> v1.1.0", result.PreviousVersion, result.Version)
+	}
+	if got := result.Targets[0]; got.Written != 1 || got.Skipped != 1 || got.Removed != 1 {
+		t.Errorf("target result = %+v, want 1 written, 1 skipped, 1 removed", got)
+	}
+	if _, err := os.Stat(filepath.Join(agentsDir, "old.md")); !os.IsNotExist(err) {
+		t.Error("old.md should have been pruned")
+	}
+	if updater.getLocalVersion() != "v1.1.0" {
+		t.Errorf("local version = %q, want v1.1.0", updater.getLocalVersion())
+	}
+
+	if err := os.RemoveAll(agentsDir); err != nil {
+		t.Fatalf("failed to remove agents dir: %v", err)
+	}
+	result, err = updater.UpdateWithResult(context.Background())
+	if err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	if !result.Downloaded || result.Reason != UpdateReasonRedownload {
+		t.Errorf("Downloaded = %v, Reason = %v, want redownload", result.Downloaded, result.Reason)
+	}
+}
+
+func TestUpdater_UpdateWithResult_metadataWarning(t *testing.T) {
+	tmpDir := t.TempDir()
+	blocker := filepath.Join(tmpDir, "blocker")
+	if err := os.MkdirAll(blocker, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+
+	latest := "v1.0.0"
+	server := newFakeGitHub(t, &latest, map[string]fakeRelease{
+		"v1.0.0": {tag: "v1.0.0", files: map[string]string{"a.md": "a"}},
+	})
+
+	updater := mustNewUpdater(t, UpdaterConfig{
+		MetadataFile: blocker,
+		Targets:      []ExtractTarget{{PathTransformer: &KeepAllTransformer{}, DestDir: filepath.Join(tmpDir, "dest")}},
+	})
+	useFakeGitHub(t, updater, server)
+
+	result, err := updater.UpdateWithResult(context.Background())
+	if err != nil {
+		t.Fatalf("UpdateWithResult() error = %v", err)
+	}
+	if len(result.Warnings) != 1 {
+		t.Errorf("Warnings = %v, want one metadata warning", result.Warnings)
+	}
+}
+
+func countingHandler(next http.Handler, prefix string, count *atomic.Int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			count.Add(1)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,38 @@
+package ghrelease
+
+import (
+	"context"
+	"os"
+	"sort"
+)
+
+func (u *Updater) Verify(ctx context.Context) (*VerifyResult, error) {
+	u.runMu.Lock()
+	defer u.runMu.Unlock()
+
+	metadata := u.loadMetadata()
+	result := &VerifyResult{Version: metadata.Version, Files: len(metadata.Files)}
+
+	paths := make([]string, 0, len(metadata.Files))
+	for path := range metadata.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		localHash, err := hashFile(path)
+		switch {
+		case os.IsNotExist(err):
+			result.Missing = append(result.Missing, path)
+		case err != nil:
+			return nil, err
+		case localHash != metadata.Files[path]:
+			result.Modified = append(result.Modified, path)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,100 @@
+package ghrelease
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUpdater_Verify(t *testing.T) {
+	tests := []struct {
+		name         string
+		modify       func(t *testing.T, dir string)
+		wantMissing  []string
+		wantModified []string
+	}{
+		{
+			name:   "intact",
+			modify: func(t *testing.T, dir string) {},
+		},
+		{
+			name: "missing file",
+			modify: func(t *testing.T, dir string) {
+				if err := os.Remove(filepath.Join(dir, "a.md")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantMissing: []string{"a.md"},
+		},
+		{
+			name: "modified file",
+			modify: func(t *testing.T, dir string) {
+				writeTestFiles(t, map[string]string{filepath.Join(dir, "sub", "b.md"): "changed"})
+			},
+			wantModified: []string{"sub/b.md"},
+		},
+		{
+			name: "untracked file is ignored",
+			modify: func(t *testing.T, dir string) {
+				writeTestFiles(t, map[string]string{filepath.Join(dir, "extra.md"): "extra"})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			destDir := filepath.Join(tmpDir, "dest")
+			files := map[string]string{
+				filepath.Join(destDir, "a.md"):        "a",
+				filepath.Join(destDir, "sub", "b.md"): "b",
+			}
+			writeTestFiles(t, files)
+
+			updater := mustNewUpdater(t, UpdaterConfig{
+				MetadataFile: filepath.Join(tmpDir, "metadata.json"),
+				Targets:      []ExtractTarget{{PathTransformer: &KeepAllTransformer{}, DestDir: destDir}},
+			})
+			hashes := make(map[string]string, len(files))
+			for path, content := range files {
+				hashes[path] = hashBytes([]byte(content))
+			}
+			if err := updater.saveMetadata(Metadata{Version: "v1.0.0", Files: hashes}); err != nil {
+				t.Fatalf("saveMetadata() error = %v", err)
+			}
+
+			tt.modify(t, destDir)
+
+			result, err := updater.Verify(context.Background())
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if result.Version != "v1.0.0" || result.Files != 2 {
+				t.Errorf("Verify() = %+v, want 2 files at v1.0.0", result)
+			}
+			if got := relPaths(destDir, result.Missing); !reflect.DeepEqual(got, tt.wantMissing) {
+				t.Errorf("Missing = %v, want %v", got, tt.wantMissing)
+			}
+			if got := relPaths(destDir, result.Modified); !reflect.DeepEqual(got, tt.wantModified) {
+				t.Errorf("Modified = %v, want %v", got, tt.wantModified)
+			}
+			if result.OK() != (tt.wantMissing == nil && tt.wantModified == nil) {
+				t.Errorf("OK() = %v", result.OK())
+			}
+		})
+	}
+}
+
+func relPaths(dir string, paths []string) []string {
+	var rel []string
+	for _, path := range paths {
+		r, err := filepath.Rel(dir, path)
+		if err != nil {
+			r = path
+		}
+		rel = append(rel, filepath.ToSlash(r))
+	}
+	return rel
+}
@@ -0,0 +1,32 @@
+package ghrelease
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "v1.0.0", b: "1.0.0", want: 0},
+		{a: "v1.2.0", b: "v1.10.0", want: -1},
+		{a: "v2.0.0", b: "v1.99.99", want: 1},
+		{a: "v1.0", b: "v1.0.1", want: -1},
+		{a: "v1.0.0-rc1", b: "v1.0.0", want: -1},
+		{a: "v1.0.0", b: "v1.0.0-beta", want: 1},
+		{a: "v1.0.0-beta.2", b: "v1.0.0-beta.10", want: -1},
+		{a: "v1.0.0-alpha", b: "v1.0.0-beta", want: -1},
+		{a: "v1.0.0+build.5", b: "v1.0.0+build.7", want: 0},
+		{a: "v1.0.0-rc.1", b: "v1.0.0-rc.x", want: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			if got := CompareVersions(tt.a, tt.b); got != tt.want {
+				t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+			if got := CompareVersions(tt.b, tt.a); got != -tt.want {
+				t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+			}
+		})
+	}
+}